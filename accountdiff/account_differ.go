@@ -0,0 +1,228 @@
+package accountdiff
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/rogeriofbrito/go-insta-scraper-v2/avatarhash"
+	"github.com/rogeriofbrito/go-insta-scraper-v2/config"
+)
+
+// Account is an account seen in a list capture.
+type Account struct {
+	Username     string `json:"username"`
+	AvatarHash   uint64 `json:"avatar_hash"`   // Perceptual hash of the profile picture, valid if AvatarHashed
+	AvatarHashed bool   `json:"avatar_hashed"` // Whether the profile picture was hashed
+}
+
+// Rename is an account whose username changed between two captures, recognized by its profile picture.
+type Rename struct {
+	Previous Account // Account as seen in the previous capture
+	Current  Account // Account as seen in the current capture
+	Distance int     // Distance between the avatar hashes of both
+}
+
+// Diff holds the differences between two captures of the same list.
+type Diff struct {
+	New     []Account // Accounts only in the current capture (e.g. new followers)
+	Gone    []Account // Accounts only in the previous capture (e.g. unfollows)
+	Renamed []Rename  // Probable renames, left out of New and Gone
+}
+
+// NewAccountDiffer creates a new AccountDiffer with the rename threshold of config.
+func NewAccountDiffer(config *config.Config) *AccountDiffer {
+	return &AccountDiffer{
+		config: config,
+	}
+}
+
+// AccountDiffer compares captures of the same list taken at different times.
+type AccountDiffer struct {
+	config *config.Config
+}
+
+// Compare returns the accounts that appeared and disappeared between the previous and current captures.
+// A disappeared account and an appeared one whose avatar hashes are at most RenameMaxAvatarDistance apart
+// are reported as a probable rename instead, closest pairs first. Avatars that are as close to another
+// account's avatar of the same capture (e.g. the default profile picture) do not identify an account,
+// so they are never paired.
+func (d *AccountDiffer) Compare(previous, current []Account) Diff {
+	previousUsernames := getUsernames(previous)
+	currentUsernames := getUsernames(current)
+
+	var gone, appeared []Account
+	for _, account := range previous {
+		if !currentUsernames[account.Username] {
+			gone = append(gone, account)
+		}
+	}
+	for _, account := range current {
+		if !previousUsernames[account.Username] {
+			appeared = append(appeared, account)
+		}
+	}
+
+	renames := d.getRenames(gone, appeared, d.getAmbiguous(previous), d.getAmbiguous(current))
+
+	diff := Diff{Renamed: renames}
+	renamedPrevious := map[string]bool{}
+	renamedCurrent := map[string]bool{}
+	for _, rename := range renames {
+		renamedPrevious[rename.Previous.Username] = true
+		renamedCurrent[rename.Current.Username] = true
+	}
+	for _, account := range gone {
+		if !renamedPrevious[account.Username] {
+			diff.Gone = append(diff.Gone, account)
+		}
+	}
+	for _, account := range appeared {
+		if !renamedCurrent[account.Username] {
+			diff.New = append(diff.New, account)
+		}
+	}
+
+	return diff
+}
+
+// getRenames pairs gone and appeared accounts with close avatar hashes, each account in at most one pair.
+func (d *AccountDiffer) getRenames(gone, appeared []Account, previousAmbiguous, currentAmbiguous map[string]bool) []Rename {
+	var candidates []Rename
+	for _, previousAccount := range gone {
+		if !previousAccount.AvatarHashed || previousAmbiguous[previousAccount.Username] {
+			continue
+		}
+		for _, currentAccount := range appeared {
+			if !currentAccount.AvatarHashed || currentAmbiguous[currentAccount.Username] {
+				continue
+			}
+
+			distance := avatarhash.Distance(previousAccount.AvatarHash, currentAccount.AvatarHash)
+			if distance <= d.config.RenameMaxAvatarDistance {
+				candidates = append(candidates, Rename{Previous: previousAccount, Current: currentAccount, Distance: distance})
+			}
+		}
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].Distance < candidates[j].Distance
+	})
+
+	var renames []Rename
+	pairedPrevious := map[string]bool{}
+	pairedCurrent := map[string]bool{}
+	for _, candidate := range candidates {
+		if pairedPrevious[candidate.Previous.Username] || pairedCurrent[candidate.Current.Username] {
+			continue
+		}
+		pairedPrevious[candidate.Previous.Username] = true
+		pairedCurrent[candidate.Current.Username] = true
+		renames = append(renames, candidate)
+	}
+
+	return renames
+}
+
+// Carry returns current along with the accounts of previous it lacks that match an account of unsure,
+// so an account whose row was seen but not confidently read (e.g. flagged for review, truncated or
+// invalid) is neither reported as gone nor dropped from the snapshot. A previous account matches an unsure
+// one with the same username, with an unambiguous avatar hash at most RenameMaxAvatarDistance apart, or
+// whose username starts with the unsure username (a truncated read); the closest match wins, and each
+// previous account is carried at most once.
+func (d *AccountDiffer) Carry(previous, current, unsure []Account) []Account {
+	currentUsernames := getUsernames(current)
+	previousAmbiguous := d.getAmbiguous(previous)
+	carried := append([]Account(nil), current...)
+	carriedUsernames := map[string]bool{}
+	for _, unsureAccount := range unsure {
+		best, bestDistance := -1, 0
+		for i, previousAccount := range previous {
+			if currentUsernames[previousAccount.Username] || carriedUsernames[previousAccount.Username] {
+				continue
+			}
+			distance, matches := d.matchUnsure(previousAccount, unsureAccount, previousAmbiguous)
+			if matches && (best < 0 || distance < bestDistance) {
+				best, bestDistance = i, distance
+			}
+		}
+		if best >= 0 {
+			carriedUsernames[previous[best].Username] = true
+			carried = append(carried, previous[best])
+		}
+	}
+
+	return carried
+}
+
+// matchUnsure returns how far apart previousAccount and unsureAccount are, 0 for the same username and
+// more than RenameMaxAvatarDistance for a username prefix, and whether they match at all.
+func (d *AccountDiffer) matchUnsure(previousAccount, unsureAccount Account, previousAmbiguous map[string]bool) (int, bool) {
+	if unsureAccount.Username != "" && previousAccount.Username == unsureAccount.Username {
+		return 0, true
+	}
+	if previousAccount.AvatarHashed && unsureAccount.AvatarHashed && !previousAmbiguous[previousAccount.Username] {
+		distance := avatarhash.Distance(previousAccount.AvatarHash, unsureAccount.AvatarHash)
+		if distance <= d.config.RenameMaxAvatarDistance {
+			return distance, true
+		}
+	}
+	if unsureAccount.Username != "" && strings.HasPrefix(previousAccount.Username, unsureAccount.Username) {
+		return d.config.RenameMaxAvatarDistance + 1, true
+	}
+
+	return 0, false
+}
+
+// Dedupe returns accounts without repeated usernames, as when they are gathered from overlapping frames of
+// the same capture. The first occurrence of each username is kept, so the order of accounts is preserved,
+// but it takes the avatar hash of a later occurrence when its own avatar was not hashed (e.g. the profile
+// picture was cut by the frame edge).
+func Dedupe(accounts []Account) []Account {
+	var deduped []Account
+	indexes := map[string]int{}
+	for _, account := range accounts {
+		i, found := indexes[account.Username]
+		if !found {
+			indexes[account.Username] = len(deduped)
+			deduped = append(deduped, account)
+			continue
+		}
+		if !deduped[i].AvatarHashed && account.AvatarHashed {
+			deduped[i].AvatarHash = account.AvatarHash
+			deduped[i].AvatarHashed = true
+		}
+	}
+
+	return deduped
+}
+
+func getUsernames(accounts []Account) map[string]bool {
+	usernames := map[string]bool{}
+	for _, account := range accounts {
+		usernames[account.Username] = true
+	}
+
+	return usernames
+}
+
+// getAmbiguous returns the usernames of the accounts whose avatar hash is at most RenameMaxAvatarDistance
+// apart from the avatar hash of another account of the same capture.
+func (d *AccountDiffer) getAmbiguous(accounts []Account) map[string]bool {
+	ambiguous := map[string]bool{}
+	for i, account := range accounts {
+		if !account.AvatarHashed {
+			continue
+		}
+		for _, other := range accounts[i+1:] {
+			if !other.AvatarHashed || other.Username == account.Username {
+				continue
+			}
+			if avatarhash.Distance(account.AvatarHash, other.AvatarHash) <= d.config.RenameMaxAvatarDistance {
+				ambiguous[account.Username] = true
+				ambiguous[other.Username] = true
+			}
+		}
+	}
+
+	return ambiguous
+}
@@ -0,0 +1,319 @@
+package accountdiff_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/rogeriofbrito/go-insta-scraper-v2/accountdiff"
+	"github.com/rogeriofbrito/go-insta-scraper-v2/config"
+)
+
+func TestAccountDiffer_Compare_DiverseCases(t *testing.T) {
+	const (
+		avatarA = uint64(0xF0F0F0F0F0F0F0F0)
+		avatarB = uint64(0x0F0F0F0F0F0F0F0F)
+		// Default profile picture, shared by accounts without one
+		avatarDefault = uint64(0xAAAAAAAAAAAAAAAA)
+	)
+
+	hashed := func(username string, hash uint64) accountdiff.Account {
+		return accountdiff.Account{Username: username, AvatarHash: hash, AvatarHashed: true}
+	}
+
+	tests := []struct {
+		name            string
+		previous        []accountdiff.Account
+		current         []accountdiff.Account
+		expectedNew     []string
+		expectedGone    []string
+		expectedRenamed []string // "previous->current"
+	}{
+		{
+			name:     "same_accounts_no_changes",
+			previous: []accountdiff.Account{hashed("maria", avatarA), hashed("joao", avatarB)},
+			current:  []accountdiff.Account{hashed("maria", avatarA), hashed("joao", avatarB)},
+		},
+		{
+			name:            "rename_with_slightly_different_hash",
+			previous:        []accountdiff.Account{hashed("maria", avatarA), hashed("joao", avatarB)},
+			current:         []accountdiff.Account{hashed("maria.silva", avatarA^0b111), hashed("joao", avatarB)},
+			expectedRenamed: []string{"maria->maria.silva"},
+		},
+		{
+			name:         "different_avatar_is_unfollow_and_new_follower",
+			previous:     []accountdiff.Account{hashed("maria", avatarA)},
+			current:      []accountdiff.Account{hashed("joao", avatarB)},
+			expectedNew:  []string{"joao"},
+			expectedGone: []string{"maria"},
+		},
+		{
+			name:         "unhashed_accounts_are_not_paired",
+			previous:     []accountdiff.Account{{Username: "maria"}},
+			current:      []accountdiff.Account{{Username: "maria.silva"}},
+			expectedNew:  []string{"maria.silva"},
+			expectedGone: []string{"maria"},
+		},
+		{
+			name:         "shared_default_avatar_is_not_paired",
+			previous:     []accountdiff.Account{hashed("maria", avatarDefault), hashed("joao", avatarDefault^1)},
+			current:      []accountdiff.Account{hashed("ana", avatarDefault), hashed("joao", avatarDefault^1)},
+			expectedNew:  []string{"ana"},
+			expectedGone: []string{"maria"},
+		},
+		{
+			name:     "closest_pair_wins",
+			previous: []accountdiff.Account{hashed("maria", avatarA), hashed("joao", avatarB)},
+			// Both are close to maria's avatar, but 11 bits apart from each other
+			current:         []accountdiff.Account{hashed("ana", avatarA^0x3FF), hashed("maria.silva", avatarA^(1<<20))},
+			expectedNew:     []string{"ana"},
+			expectedGone:    []string{"joao"},
+			expectedRenamed: []string{"maria->maria.silva"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := config.Config{RenameMaxAvatarDistance: 10}
+			d := accountdiff.NewAccountDiffer(&cfg)
+
+			diff := d.Compare(tc.previous, tc.current)
+
+			var renamed []string
+			for _, rename := range diff.Renamed {
+				renamed = append(renamed, rename.Previous.Username+"->"+rename.Current.Username)
+			}
+			assertUsernames(t, "New", usernames(diff.New), tc.expectedNew)
+			assertUsernames(t, "Gone", usernames(diff.Gone), tc.expectedGone)
+			assertUsernames(t, "Renamed", renamed, tc.expectedRenamed)
+		})
+	}
+}
+
+func TestAccountDiffer_Carry_DiverseCases(t *testing.T) {
+	const (
+		avatarA = uint64(0xF0F0F0F0F0F0F0F0)
+		avatarB = uint64(0x0F0F0F0F0F0F0F0F)
+	)
+
+	hashed := func(username string, hash uint64) accountdiff.Account {
+		return accountdiff.Account{Username: username, AvatarHash: hash, AvatarHashed: true}
+	}
+
+	tests := []struct {
+		name     string
+		previous []accountdiff.Account
+		current  []accountdiff.Account
+		unsure   []accountdiff.Account
+		expected []string
+	}{
+		{
+			name:     "no_unsure_rows",
+			previous: []accountdiff.Account{hashed("maria", avatarA), hashed("joao", avatarB)},
+			current:  []accountdiff.Account{hashed("maria", avatarA)},
+			expected: []string{"maria"},
+		},
+		{
+			name:     "unsure_read_of_the_same_username",
+			previous: []accountdiff.Account{hashed("maria", avatarA), hashed("joao", avatarB)},
+			current:  []accountdiff.Account{hashed("maria", avatarA)},
+			unsure:   []accountdiff.Account{{Username: "joao"}},
+			expected: []string{"maria", "joao"},
+		},
+		{
+			name:     "invalid_read_with_the_same_avatar",
+			previous: []accountdiff.Account{hashed("maria", avatarA), hashed("joao", avatarB)},
+			current:  []accountdiff.Account{hashed("maria", avatarA)},
+			unsure:   []accountdiff.Account{hashed("", avatarB^0b11)},
+			expected: []string{"maria", "joao"},
+		},
+		{
+			name:     "truncated_read_of_the_username",
+			previous: []accountdiff.Account{hashed("maria", avatarA), {Username: "joao.silva.oficial"}},
+			current:  []accountdiff.Account{hashed("maria", avatarA)},
+			unsure:   []accountdiff.Account{{Username: "joao.silva"}},
+			expected: []string{"maria", "joao.silva.oficial"},
+		},
+		{
+			name:     "unmatched_unsure_row_carries_nothing",
+			previous: []accountdiff.Account{hashed("maria", avatarA), hashed("joao", avatarB)},
+			current:  []accountdiff.Account{hashed("maria", avatarA)},
+			unsure:   []accountdiff.Account{hashed("ana", avatarA^avatarB)},
+			expected: []string{"maria"},
+		},
+		{
+			name:     "account_read_confidently_is_not_carried_twice",
+			previous: []accountdiff.Account{hashed("maria", avatarA)},
+			current:  []accountdiff.Account{hashed("maria", avatarA)},
+			unsure:   []accountdiff.Account{hashed("maria", avatarA)},
+			expected: []string{"maria"},
+		},
+		{
+			name:     "each_previous_account_carried_once",
+			previous: []accountdiff.Account{hashed("joao", avatarB)},
+			unsure:   []accountdiff.Account{{Username: "joao"}, hashed("", avatarB)},
+			expected: []string{"joao"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := config.Config{RenameMaxAvatarDistance: 10}
+			d := accountdiff.NewAccountDiffer(&cfg)
+
+			carried := d.Carry(tc.previous, tc.current, tc.unsure)
+
+			assertUsernames(t, "Carry", usernames(carried), tc.expected)
+		})
+	}
+}
+
+func TestDedupe_DiverseCases(t *testing.T) {
+	tests := []struct {
+		name     string
+		accounts []accountdiff.Account
+		expected []accountdiff.Account
+	}{
+		{
+			name:     "no_accounts",
+			accounts: nil,
+			expected: nil,
+		},
+		{
+			name: "overlapping_frames_keep_capture_order",
+			accounts: []accountdiff.Account{
+				{Username: "maria", AvatarHash: 1, AvatarHashed: true},
+				{Username: "joao", AvatarHash: 2, AvatarHashed: true},
+				{Username: "joao", AvatarHash: 2, AvatarHashed: true},
+				{Username: "ana", AvatarHash: 3, AvatarHashed: true},
+				{Username: "maria", AvatarHash: 1, AvatarHashed: true},
+			},
+			expected: []accountdiff.Account{
+				{Username: "maria", AvatarHash: 1, AvatarHashed: true},
+				{Username: "joao", AvatarHash: 2, AvatarHashed: true},
+				{Username: "ana", AvatarHash: 3, AvatarHashed: true},
+			},
+		},
+		{
+			name: "later_hashed_avatar_fills_unhashed_one",
+			accounts: []accountdiff.Account{
+				{Username: "maria"},
+				{Username: "joao", AvatarHash: 2, AvatarHashed: true},
+				{Username: "maria", AvatarHash: 1, AvatarHashed: true},
+			},
+			expected: []accountdiff.Account{
+				{Username: "maria", AvatarHash: 1, AvatarHashed: true},
+				{Username: "joao", AvatarHash: 2, AvatarHashed: true},
+			},
+		},
+		{
+			name: "first_hashed_avatar_is_kept",
+			accounts: []accountdiff.Account{
+				{Username: "maria", AvatarHash: 1, AvatarHashed: true},
+				{Username: "maria", AvatarHash: 9, AvatarHashed: true},
+			},
+			expected: []accountdiff.Account{
+				{Username: "maria", AvatarHash: 1, AvatarHashed: true},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := accountdiff.Dedupe(tt.accounts)
+			if len(got) != len(tt.expected) {
+				t.Fatalf("Dedupe() = %v; expected %v", got, tt.expected)
+			}
+			for i := range got {
+				if got[i] != tt.expected[i] {
+					t.Fatalf("Dedupe() = %v; expected %v", got, tt.expected)
+				}
+			}
+		})
+	}
+}
+
+func TestLoadSnapshot_DiverseCases(t *testing.T) {
+	tests := []struct {
+		name              string
+		snapshotPath      string
+		expectedUsernames []string
+		expectErr         bool
+	}{
+		{
+			name:              "existing_snapshot",
+			snapshotPath:      "testdata/snapshot.json",
+			expectedUsernames: []string{"maria.silva", "joao_123"},
+		},
+		{
+			name:              "missing_snapshot_returns_empty",
+			snapshotPath:      "testdata/missing_snapshot.json",
+			expectedUsernames: nil,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			snapshot, err := accountdiff.LoadSnapshot(tc.snapshotPath)
+			if tc.expectErr {
+				if err == nil {
+					t.Fatalf("expected error but got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			assertUsernames(t, "Accounts", usernames(snapshot.Accounts), tc.expectedUsernames)
+		})
+	}
+}
+
+func TestSnapshot_SaveAndLoad(t *testing.T) {
+	snapshotPath := filepath.Join(os.TempDir(), "go-insta-scraper-v2-test-snapshot.json")
+	_ = os.Remove(snapshotPath)
+	defer os.Remove(snapshotPath)
+
+	snapshot := &accountdiff.Snapshot{
+		TakenAt: time.Date(2025, 9, 1, 10, 0, 0, 0, time.UTC),
+		Accounts: []accountdiff.Account{
+			{Username: "maria.silva", AvatarHash: 0xAAAAAAAAAAAAAAAA, AvatarHashed: true},
+		},
+	}
+	if err := snapshot.Save(snapshotPath); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	loaded, err := accountdiff.LoadSnapshot(snapshotPath)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !loaded.TakenAt.Equal(snapshot.TakenAt) {
+		t.Errorf("TakenAt = %v; expected %v", loaded.TakenAt, snapshot.TakenAt)
+	}
+	if len(loaded.Accounts) != 1 || loaded.Accounts[0] != snapshot.Accounts[0] {
+		t.Errorf("Accounts = %v; expected %v", loaded.Accounts, snapshot.Accounts)
+	}
+}
+
+func usernames(accounts []accountdiff.Account) []string {
+	var usernames []string
+	for _, account := range accounts {
+		usernames = append(usernames, account.Username)
+	}
+	return usernames
+}
+
+func assertUsernames(t *testing.T, field string, got, expected []string) {
+	t.Helper()
+	if len(got) != len(expected) {
+		t.Fatalf("%s = %v; expected %v", field, got, expected)
+	}
+	for i := range got {
+		if got[i] != expected[i] {
+			t.Fatalf("%s = %v; expected %v", field, got, expected)
+		}
+	}
+}
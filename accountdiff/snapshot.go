@@ -0,0 +1,51 @@
+package accountdiff
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"time"
+
+	"github.com/palantir/stacktrace"
+)
+
+// Snapshot is a stored capture of a list, compared against the next capture to find what changed.
+type Snapshot struct {
+	TakenAt  time.Time `json:"taken_at"`
+	Accounts []Account `json:"accounts"`
+}
+
+// LoadSnapshot reads the snapshot at snapshotPath.
+// Returns an empty snapshot if there is no snapshot yet (e.g. first run).
+func LoadSnapshot(snapshotPath string) (*Snapshot, error) {
+	snapshotBytes, err := os.ReadFile(snapshotPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return &Snapshot{}, nil
+	}
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "failed to read snapshot at %s", snapshotPath)
+	}
+
+	snapshot := &Snapshot{}
+	err = json.Unmarshal(snapshotBytes, snapshot)
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "failed to parse snapshot at %s", snapshotPath)
+	}
+
+	return snapshot, nil
+}
+
+// Save writes the snapshot at snapshotPath.
+func (s *Snapshot) Save(snapshotPath string) error {
+	snapshotBytes, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return stacktrace.Propagate(err, "failed to encode snapshot")
+	}
+
+	err = os.WriteFile(snapshotPath, append(snapshotBytes, '\n'), 0644)
+	if err != nil {
+		return stacktrace.Propagate(err, "failed to write snapshot at %s", snapshotPath)
+	}
+
+	return nil
+}
@@ -0,0 +1,90 @@
+package avatardetector
+
+import (
+	"image"
+	"math"
+	"sort"
+
+	"github.com/palantir/stacktrace"
+	"github.com/rogeriofbrito/go-insta-scraper-v2/config"
+	"gocv.io/x/gocv"
+)
+
+const (
+	blurKernelSize   = 5   // Median blur kernel size used to remove noise before detecting circles
+	houghDp          = 1   // Inverse ratio of the accumulator resolution to the image resolution
+	houghCannyThresh = 100 // Higher threshold of the Canny edge detector used by HoughCircles
+)
+
+// NewAvatarDetector creates a new AvatarDetector with the avatar search parameters of config.
+func NewAvatarDetector(config *config.Config) *AvatarDetector {
+	return &AvatarDetector{
+		config: config,
+	}
+}
+
+// AvatarDetector finds the circular profile pictures of user rows in screenshots.
+type AvatarDetector struct {
+	config *config.Config
+}
+
+// GetAvatars finds the profile pictures whose center is inside AvatarSearchRect.
+// Returns the bounding rectangles of the circles found, from top to bottom.
+func (ad *AvatarDetector) GetAvatars(imageMat gocv.Mat) ([]image.Rectangle, error) {
+	grayMat := gocv.NewMat()
+	defer grayMat.Close()
+
+	var err error
+	if imageMat.Channels() == 1 {
+		err = imageMat.CopyTo(&grayMat)
+	} else {
+		err = gocv.CvtColor(imageMat, &grayMat, gocv.ColorBGRToGray)
+	}
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "failed to convert image to grayscale")
+	}
+
+	err = gocv.MedianBlur(grayMat, &grayMat, blurKernelSize)
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "failed to blur image")
+	}
+
+	circles := gocv.NewMat()
+	defer circles.Close()
+
+	// Two avatars can't be closer than a diameter apart
+	minDist := float64(2 * ad.config.AvatarMinRadius)
+	err = gocv.HoughCirclesWithParams(
+		grayMat,
+		&circles,
+		gocv.HoughGradient,
+		houghDp,
+		math.Max(minDist, 1),
+		houghCannyThresh,
+		ad.config.AvatarHoughThreshold,
+		ad.config.AvatarMinRadius,
+		ad.config.AvatarMaxRadius,
+	)
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "failed to detect circles")
+	}
+
+	var avatars []image.Rectangle
+	for i := 0; i < circles.Cols(); i++ {
+		circle := circles.GetVecfAt(0, i)
+		center := image.Pt(int(math.Round(float64(circle[0]))), int(math.Round(float64(circle[1]))))
+		radius := int(math.Round(float64(circle[2])))
+
+		if !center.In(ad.config.AvatarSearchRect) {
+			continue
+		}
+
+		avatars = append(avatars, image.Rect(center.X-radius, center.Y-radius, center.X+radius, center.Y+radius))
+	}
+
+	sort.Slice(avatars, func(i, j int) bool {
+		return avatars[i].Min.Y < avatars[j].Min.Y
+	})
+
+	return avatars, nil
+}
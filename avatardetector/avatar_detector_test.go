@@ -0,0 +1,83 @@
+package avatardetector_test
+
+import (
+	"image"
+	"testing"
+
+	"github.com/rogeriofbrito/go-insta-scraper-v2/avatardetector"
+	"github.com/rogeriofbrito/go-insta-scraper-v2/config"
+	"gocv.io/x/gocv"
+)
+
+func TestAvatarDetector_GetAvatars_DiverseCases(t *testing.T) {
+	tests := []struct {
+		name            string
+		imagePath       string
+		flags           gocv.IMReadFlag
+		searchRect      image.Rectangle
+		expectedCenters []image.Point
+	}{
+		{
+			name:            "avatars_column_color",
+			imagePath:       "testdata/avatars_column.png",
+			flags:           gocv.IMReadColor,
+			searchRect:      image.Rect(0, 0, 200, 620),
+			expectedCenters: []image.Point{{91, 86}, {91, 235}, {91, 384}, {91, 533}},
+		},
+		{
+			name:            "avatars_column_grayscale",
+			imagePath:       "testdata/avatars_column.png",
+			flags:           gocv.IMReadGrayScale,
+			searchRect:      image.Rect(0, 0, 200, 620),
+			expectedCenters: []image.Point{{91, 86}, {91, 235}, {91, 384}, {91, 533}},
+		},
+		{
+			name:            "avatars_outside_search_rect_ignored",
+			imagePath:       "testdata/avatars_column.png",
+			flags:           gocv.IMReadColor,
+			searchRect:      image.Rect(0, 150, 200, 450),
+			expectedCenters: []image.Point{{91, 235}, {91, 384}},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			imageMat := gocv.IMRead(tc.imagePath, tc.flags)
+			if imageMat.Empty() {
+				t.Fatalf("failed to load image: %s", tc.imagePath)
+			}
+			defer imageMat.Close()
+
+			cfg := config.Config{
+				AvatarSearchRect:     tc.searchRect,
+				AvatarMinRadius:      50,
+				AvatarMaxRadius:      70,
+				AvatarHoughThreshold: 40,
+			}
+			ad := avatardetector.NewAvatarDetector(&cfg)
+
+			avatars, err := ad.GetAvatars(imageMat)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(avatars) != len(tc.expectedCenters) {
+				t.Fatalf("GetAvatars(%s) = %v; expected centers %v", tc.imagePath, avatars, tc.expectedCenters)
+			}
+			for i, avatar := range avatars {
+				center := avatar.Min.Add(avatar.Max).Div(2)
+				if abs(center.X-tc.expectedCenters[i].X) > 8 || abs(center.Y-tc.expectedCenters[i].Y) > 8 {
+					t.Errorf("GetAvatars(%s)[%d] center = %v; expected about %v", tc.imagePath, i, center, tc.expectedCenters[i])
+				}
+			}
+		})
+	}
+}
+
+// --- helpers ---
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
@@ -0,0 +1,113 @@
+package avatarhash
+
+import (
+	"image"
+	"math"
+	"math/bits"
+
+	"github.com/palantir/stacktrace"
+	"github.com/rogeriofbrito/go-insta-scraper-v2/config"
+	"github.com/rogeriofbrito/go-insta-scraper-v2/util"
+	"gocv.io/x/gocv"
+)
+
+const (
+	dctSize  = 32 // Side of the image the DCT is computed over
+	hashSize = 8  // Side of the block of lowest frequencies kept in the hash, one bit each
+	// innerRatio is the radius, as a fraction of the avatar radius, of the circle whose inscribed square is
+	// hashed. It leaves out the story ring and the background around the circle, which change over time.
+	innerRatio = 0.75
+)
+
+// NewAvatarHasher creates a new AvatarHasher.
+func NewAvatarHasher(config *config.Config) *AvatarHasher {
+	return &AvatarHasher{
+		config: config,
+	}
+}
+
+// AvatarHasher computes perceptual hashes of profile pictures, which stay the same (or nearly) when the
+// same picture is captured again, in another frame or another day, at another scale or brightness.
+type AvatarHasher struct {
+	config *config.Config
+}
+
+// Hash returns the perceptual hash (pHash) of the profile picture at avatarRect: the sign, relative to
+// their median, of the lowest frequencies of the discrete cosine transform of the picture.
+func (h *AvatarHasher) Hash(imageMat gocv.Mat, avatarRect image.Rectangle) (uint64, error) {
+	innerRect := GetInnerRect(avatarRect)
+	if !innerRect.In(image.Rect(0, 0, imageMat.Cols(), imageMat.Rows())) || innerRect.Empty() {
+		return 0, stacktrace.NewError("avatar rect %v is not inside the image", avatarRect)
+	}
+
+	grayMat, err := util.GetGrayRegion(imageMat, innerRect)
+	if err != nil {
+		return 0, stacktrace.Propagate(err, "failed to get gray avatar region")
+	}
+	defer grayMat.Close()
+
+	resizedMat := gocv.NewMat()
+	defer resizedMat.Close()
+	err = gocv.Resize(grayMat, &resizedMat, image.Pt(dctSize, dctSize), 0, 0, gocv.InterpolationArea)
+	if err != nil {
+		return 0, stacktrace.Propagate(err, "failed to resize avatar")
+	}
+
+	floatMat := gocv.NewMat()
+	defer floatMat.Close()
+	err = resizedMat.ConvertTo(&floatMat, gocv.MatTypeCV32F)
+	if err != nil {
+		return 0, stacktrace.Propagate(err, "failed to convert avatar to float")
+	}
+
+	dctMat := gocv.NewMat()
+	defer dctMat.Close()
+	err = gocv.DCT(floatMat, &dctMat, gocv.DftForward)
+	if err != nil {
+		return 0, stacktrace.Propagate(err, "failed to compute avatar DCT")
+	}
+
+	var coefficients []float64
+	for y := 0; y < hashSize; y++ {
+		for x := 0; x < hashSize; x++ {
+			coefficients = append(coefficients, float64(dctMat.GetFloatAt(y, x)))
+		}
+	}
+
+	return HashFromCoefficients(coefficients), nil
+}
+
+// GetInnerRect returns the square inscribed in the inner circle of the profile picture at avatarRect.
+func GetInnerRect(avatarRect image.Rectangle) image.Rectangle {
+	center := avatarRect.Min.Add(avatarRect.Max).Div(2)
+	radius := float64(min(avatarRect.Dx(), avatarRect.Dy())) / 2
+	halfSide := int(innerRatio * radius / math.Sqrt2)
+
+	return image.Rect(center.X-halfSide, center.Y-halfSide, center.X+halfSide, center.Y+halfSide)
+}
+
+// HashFromCoefficients returns the hash of the given DCT coefficients, lowest frequencies first and row
+// by row: bit i is set when coefficient i is above the median. The first coefficient (the average
+// brightness) is left out of the median so a brighter or darker capture gives the same hash.
+func HashFromCoefficients(coefficients []float64) uint64 {
+	if len(coefficients) < 2 {
+		return 0
+	}
+
+	median := util.Median(coefficients[1:])
+
+	var hash uint64
+	for i, coefficient := range coefficients {
+		if i < 64 && coefficient > median {
+			hash |= 1 << i
+		}
+	}
+
+	return hash
+}
+
+// Distance returns the number of bits that differ between two hashes (Hamming distance). Hashes of the
+// same picture are a few bits apart, while hashes of different pictures are about 32 bits apart.
+func Distance(a, b uint64) int {
+	return bits.OnesCount64(a ^ b)
+}
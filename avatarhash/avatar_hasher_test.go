@@ -0,0 +1,146 @@
+package avatarhash_test
+
+import (
+	"image"
+	"testing"
+
+	"github.com/rogeriofbrito/go-insta-scraper-v2/avatarhash"
+	"github.com/rogeriofbrito/go-insta-scraper-v2/config"
+	"gocv.io/x/gocv"
+)
+
+func TestAvatarHasher_Hash_DiverseCases(t *testing.T) {
+	tests := []struct {
+		name        string
+		imagePathA  string
+		imagePathB  string
+		expectSame  bool
+		maxSameDist int // maximum distance between hashes of the same picture
+		minDiffDist int // minimum distance between hashes of different pictures
+	}{
+		{
+			name:        "same_picture_scaled_and_brighter",
+			imagePathA:  "testdata/avatar_1.png",
+			imagePathB:  "testdata/avatar_1_scaled.png",
+			expectSame:  true,
+			maxSameDist: 10,
+		},
+		{
+			name:        "same_picture_identical",
+			imagePathA:  "testdata/avatar_2.png",
+			imagePathB:  "testdata/avatar_2.png",
+			expectSame:  true,
+			maxSameDist: 0,
+		},
+		{
+			name:        "different_pictures",
+			imagePathA:  "testdata/avatar_1.png",
+			imagePathB:  "testdata/avatar_2.png",
+			expectSame:  false,
+			minDiffDist: 20,
+		},
+	}
+
+	h := avatarhash.NewAvatarHasher(&config.Config{})
+	hashImage := func(t *testing.T, imagePath string) uint64 {
+		imageMat := gocv.IMRead(imagePath, gocv.IMReadColor)
+		if imageMat.Empty() {
+			t.Fatalf("failed to load image: %s", imagePath)
+		}
+		defer imageMat.Close()
+
+		hash, err := h.Hash(imageMat, image.Rect(0, 0, imageMat.Cols(), imageMat.Rows()))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return hash
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			hashA := hashImage(t, tc.imagePathA)
+			hashB := hashImage(t, tc.imagePathB)
+			distance := avatarhash.Distance(hashA, hashB)
+
+			if tc.expectSame && distance > tc.maxSameDist {
+				t.Errorf("Distance(%016x, %016x) = %d; expected at most %d", hashA, hashB, distance, tc.maxSameDist)
+			}
+			if !tc.expectSame && distance < tc.minDiffDist {
+				t.Errorf("Distance(%016x, %016x) = %d; expected at least %d", hashA, hashB, distance, tc.minDiffDist)
+			}
+		})
+	}
+}
+
+func TestAvatarHasher_Hash_RectOutsideImage(t *testing.T) {
+	imageMat := gocv.IMRead("testdata/avatar_1.png", gocv.IMReadColor)
+	if imageMat.Empty() {
+		t.Fatalf("failed to load image: testdata/avatar_1.png")
+	}
+	defer imageMat.Close()
+
+	h := avatarhash.NewAvatarHasher(&config.Config{})
+	if _, err := h.Hash(imageMat, image.Rect(100, 100, 300, 300)); err == nil {
+		t.Errorf("expected error but got nil")
+	}
+}
+
+func TestHashFromCoefficients_DiverseCases(t *testing.T) {
+	tests := []struct {
+		name         string
+		coefficients []float64
+		expected     uint64
+	}{
+		{
+			name:         "too_few_coefficients",
+			coefficients: []float64{10},
+			expected:     0,
+		},
+		{
+			name:         "bits_above_median_are_set",
+			coefficients: []float64{100, 1, 5, 2, 6, 3},
+			expected:     0b010101,
+		},
+		{
+			name:         "average_brightness_is_left_out_of_median",
+			coefficients: []float64{-100, 1, 5, 2, 6, 3},
+			expected:     0b010100,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			result := avatarhash.HashFromCoefficients(tc.coefficients)
+			if result != tc.expected {
+				t.Errorf("HashFromCoefficients(%v) = %b; expected %b", tc.coefficients, result, tc.expected)
+			}
+		})
+	}
+}
+
+func TestDistance_DiverseCases(t *testing.T) {
+	tests := []struct {
+		a, b     uint64
+		expected int
+	}{
+		{a: 0, b: 0, expected: 0},
+		{a: 0b1011, b: 0b0001, expected: 2},
+		{a: 0, b: ^uint64(0), expected: 64},
+	}
+
+	for _, tc := range tests {
+		result := avatarhash.Distance(tc.a, tc.b)
+		if result != tc.expected {
+			t.Errorf("Distance(%b, %b) = %d; expected %d", tc.a, tc.b, result, tc.expected)
+		}
+	}
+}
+
+func TestGetInnerRect(t *testing.T) {
+	// Radius 66, inner radius 49.5, half side of the inscribed square 35
+	result := avatarhash.GetInnerRect(image.Rect(25, 360, 157, 492))
+	expected := image.Rect(56, 391, 126, 461)
+	if result != expected {
+		t.Errorf("GetInnerRect() = %v; expected %v", result, expected)
+	}
+}
@@ -0,0 +1,133 @@
+package capturemonitor
+
+import (
+	"fmt"
+)
+
+// NewCaptureMonitor creates a new CaptureMonitor.
+func NewCaptureMonitor() *CaptureMonitor {
+	return &CaptureMonitor{}
+}
+
+// CaptureMonitor watches the frames of a batch (e.g. the frames of a screen recording) and tells whether
+// the capture covered the whole list, so an incomplete export is not taken as the full list.
+type CaptureMonitor struct {
+	observations []observation
+}
+
+// Frame is what a single frame shows about the ends of the list.
+type Frame struct {
+	StartOfList bool     // Whether the frame shows the top of the list
+	EndOfList   bool     // Whether the frame shows the end of the list ("Suggested for you" header)
+	Spinner     bool     // Whether the frame shows the loading spinner
+	Usernames   []string // Usernames of the list rows of the frame
+}
+
+type observation struct {
+	name  string
+	frame Frame
+}
+
+// Report is the result of the completeness analysis of a batch.
+type Report struct {
+	StartedAtTop  bool     // Whether the first frame shows the top of the list, so no account above it was missed
+	ReachedEnd    bool     // Whether the capture reached the end of the list, by the header or because the list settled
+	Settled       bool     // Whether the last frame shows no loading spinner and no rows the frames before it did not show
+	StillLoading  bool     // Whether the last frame shows the loading spinner, so the recording stopped while more accounts were loading
+	EndFrame      string   // First frame showing the end of the list, or the last frame when the list settled; empty if ReachedEnd is false
+	SpinnerFrames []string // Frames showing the loading spinner, in capture order
+	Warnings      []string // Human readable description of why the capture may be incomplete
+}
+
+// Observe records what one frame of the batch shows about the ends of the list. Frames must be observed in
+// capture order.
+func (m *CaptureMonitor) Observe(name string, frame Frame) {
+	m.observations = append(m.observations, observation{
+		name:  name,
+		frame: frame,
+	})
+}
+
+// Report analyzes every frame observed so far. The capture started at the top of the list when the first
+// frame shows it, and it reached the end of the list when a frame shows the end of the list, or when the
+// list settled: the last frame shows no loading spinner and only rows that earlier frames already showed,
+// so scrolling further brings nothing new (e.g. a list without suggestions, or with DetectSuggestionsHeader
+// off). Otherwise it is incomplete, and a spinner in the last frame tells that the recording was stopped
+// before the list finished loading.
+func (m *CaptureMonitor) Report() Report {
+	var report Report
+	for _, obs := range m.observations {
+		if obs.frame.EndOfList && !report.ReachedEnd {
+			report.ReachedEnd = true
+			report.EndFrame = obs.name
+		}
+		if obs.frame.Spinner {
+			report.SpinnerFrames = append(report.SpinnerFrames, obs.name)
+		}
+	}
+
+	if len(m.observations) == 0 {
+		report.Warnings = append(report.Warnings, "no frames observed")
+		return report
+	}
+
+	first := m.observations[0]
+	report.StartedAtTop = first.frame.StartOfList
+	if !report.StartedAtTop {
+		report.Warnings = append(report.Warnings, fmt.Sprintf(
+			"capture is incomplete: first frame %s does not show the top of the list, the recording started after scrolling", first.name,
+		))
+	}
+
+	last := m.observations[len(m.observations)-1]
+	report.Settled = m.isSettled()
+	if report.Settled && !report.ReachedEnd {
+		report.ReachedEnd = true
+		report.EndFrame = last.name
+	}
+	report.StillLoading = last.frame.Spinner && !report.ReachedEnd
+	if report.ReachedEnd {
+		return report
+	}
+
+	if report.StillLoading {
+		report.Warnings = append(report.Warnings, fmt.Sprintf(
+			"capture is incomplete: last frame %s shows the loading spinner, the recording stopped before the list finished loading",
+			last.name,
+		))
+	} else {
+		report.Warnings = append(report.Warnings, fmt.Sprintf(
+			"capture may be incomplete: none of the %d frames shows the end of the list and the last frame %s still shows new rows",
+			len(m.observations), last.name,
+		))
+	}
+
+	return report
+}
+
+// isSettled reports whether the last frame shows no loading spinner and has rows, all of them shown by an
+// earlier frame.
+func (m *CaptureMonitor) isSettled() bool {
+	if len(m.observations) < 2 {
+		return false
+	}
+
+	last := m.observations[len(m.observations)-1]
+	if last.frame.Spinner || len(last.frame.Usernames) == 0 {
+		return false
+	}
+
+	seen := map[string]bool{}
+	for _, obs := range m.observations[:len(m.observations)-1] {
+		for _, username := range obs.frame.Usernames {
+			seen[username] = true
+		}
+	}
+	for _, username := range last.frame.Usernames {
+		if !seen[username] {
+			return false
+		}
+	}
+
+	return true
+}
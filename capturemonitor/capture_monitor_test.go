@@ -0,0 +1,185 @@
+package capturemonitor_test
+
+import (
+	"testing"
+
+	"github.com/rogeriofbrito/go-insta-scraper-v2/capturemonitor"
+)
+
+func TestCaptureMonitor_Report_DiverseCases(t *testing.T) {
+	type frame struct {
+		name        string
+		startOfList bool
+		endOfList   bool
+		spinner     bool
+		usernames   []string
+	}
+
+	tests := []struct {
+		name                  string
+		frames                []frame
+		expectedStartedAtTop  bool
+		expectedReachedEnd    bool
+		expectedSettled       bool
+		expectedStillLoading  bool
+		expectedEndFrame      string
+		expectedSpinnerFrames []string
+		expectedWarnings      int
+	}{
+		{
+			name:             "no_frames",
+			frames:           nil,
+			expectedWarnings: 1,
+		},
+		{
+			name:                 "end_of_list_reached",
+			expectedStartedAtTop: true,
+			frames: []frame{
+				{name: "frame_0001.png", startOfList: true},
+				{name: "frame_0002.png", spinner: true},
+				{name: "frame_0003.png", endOfList: true},
+				{name: "frame_0004.png", endOfList: true},
+			},
+			expectedReachedEnd:    true,
+			expectedEndFrame:      "frame_0003.png",
+			expectedSpinnerFrames: []string{"frame_0002.png"},
+		},
+		{
+			name:                 "suggestions_loading_after_end_of_list",
+			expectedStartedAtTop: true,
+			frames: []frame{
+				{name: "frame_0001.png", startOfList: true},
+				{name: "frame_0002.png", endOfList: true, spinner: true},
+			},
+			expectedReachedEnd:    true,
+			expectedEndFrame:      "frame_0002.png",
+			expectedSpinnerFrames: []string{"frame_0002.png"},
+		},
+		{
+			name:                 "stopped_while_loading",
+			expectedStartedAtTop: true,
+			frames: []frame{
+				{name: "frame_0001.png", startOfList: true},
+				{name: "frame_0002.png", spinner: true},
+			},
+			expectedStillLoading:  true,
+			expectedSpinnerFrames: []string{"frame_0002.png"},
+			expectedWarnings:      1,
+		},
+		{
+			name:                 "list_without_suggestions_settled",
+			expectedStartedAtTop: true,
+			frames: []frame{
+				{name: "frame_0001.png", startOfList: true, usernames: []string{"maria", "joao"}},
+				{name: "frame_0002.png", usernames: []string{"joao", "ana"}},
+				{name: "frame_0003.png", usernames: []string{"joao", "ana"}},
+			},
+			expectedReachedEnd: true,
+			expectedSettled:    true,
+			expectedEndFrame:   "frame_0003.png",
+		},
+		{
+			name:                 "last_frame_shows_new_rows",
+			expectedStartedAtTop: true,
+			frames: []frame{
+				{name: "frame_0001.png", startOfList: true, usernames: []string{"maria", "joao"}},
+				{name: "frame_0002.png", usernames: []string{"joao", "ana"}},
+			},
+			expectedWarnings: 1,
+		},
+		{
+			name:                 "settled_rows_with_spinner_still_loading",
+			expectedStartedAtTop: true,
+			frames: []frame{
+				{name: "frame_0001.png", startOfList: true, usernames: []string{"maria", "joao"}},
+				{name: "frame_0002.png", spinner: true, usernames: []string{"joao"}},
+			},
+			expectedStillLoading:  true,
+			expectedSpinnerFrames: []string{"frame_0002.png"},
+			expectedWarnings:      1,
+		},
+		{
+			name:                 "single_frame_never_settles",
+			expectedStartedAtTop: true,
+			frames: []frame{
+				{name: "frame_0001.png", startOfList: true, usernames: []string{"maria", "joao"}},
+			},
+			expectedWarnings: 1,
+		},
+		{
+			name:                 "header_seen_before_list_settled",
+			expectedStartedAtTop: true,
+			frames: []frame{
+				{name: "frame_0001.png", startOfList: true, usernames: []string{"maria"}},
+				{name: "frame_0002.png", endOfList: true, usernames: []string{"joao"}},
+				{name: "frame_0003.png", endOfList: true, usernames: []string{"joao"}},
+			},
+			expectedReachedEnd: true,
+			expectedSettled:    true,
+			expectedEndFrame:   "frame_0002.png",
+		},
+		{
+			name: "started_after_scrolling",
+			frames: []frame{
+				{name: "frame_0001.png", usernames: []string{"joao"}},
+				{name: "frame_0002.png", endOfList: true, usernames: []string{"ana"}},
+			},
+			expectedReachedEnd: true,
+			expectedEndFrame:   "frame_0002.png",
+			expectedWarnings:   1,
+		},
+		{
+			name:                 "end_of_list_never_seen",
+			expectedStartedAtTop: true,
+			frames: []frame{
+				{name: "frame_0001.png", startOfList: true, spinner: true},
+				{name: "frame_0002.png"},
+			},
+			expectedSpinnerFrames: []string{"frame_0001.png"},
+			expectedWarnings:      1,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			m := capturemonitor.NewCaptureMonitor()
+			for _, f := range tc.frames {
+				m.Observe(f.name, capturemonitor.Frame{
+					StartOfList: f.startOfList,
+					EndOfList:   f.endOfList,
+					Spinner:     f.spinner,
+					Usernames:   f.usernames,
+				})
+			}
+
+			report := m.Report()
+
+			if report.StartedAtTop != tc.expectedStartedAtTop {
+				t.Errorf("StartedAtTop = %v; expected %v", report.StartedAtTop, tc.expectedStartedAtTop)
+			}
+			if report.ReachedEnd != tc.expectedReachedEnd {
+				t.Errorf("ReachedEnd = %v; expected %v", report.ReachedEnd, tc.expectedReachedEnd)
+			}
+			if report.Settled != tc.expectedSettled {
+				t.Errorf("Settled = %v; expected %v", report.Settled, tc.expectedSettled)
+			}
+			if report.StillLoading != tc.expectedStillLoading {
+				t.Errorf("StillLoading = %v; expected %v", report.StillLoading, tc.expectedStillLoading)
+			}
+			if report.EndFrame != tc.expectedEndFrame {
+				t.Errorf("EndFrame = %q; expected %q", report.EndFrame, tc.expectedEndFrame)
+			}
+			if len(report.SpinnerFrames) != len(tc.expectedSpinnerFrames) {
+				t.Fatalf("SpinnerFrames = %v; expected %v", report.SpinnerFrames, tc.expectedSpinnerFrames)
+			}
+			for i := range report.SpinnerFrames {
+				if report.SpinnerFrames[i] != tc.expectedSpinnerFrames[i] {
+					t.Fatalf("SpinnerFrames = %v; expected %v", report.SpinnerFrames, tc.expectedSpinnerFrames)
+				}
+			}
+			if len(report.Warnings) != tc.expectedWarnings {
+				t.Errorf("Warnings = %v; expected %d warnings", report.Warnings, tc.expectedWarnings)
+			}
+		})
+	}
+}
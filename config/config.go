@@ -2,29 +2,122 @@ package config
 
 import (
 	"image"
+	"time"
 
 	"gocv.io/x/gocv"
 )
 
+// RowLocator selects how the rows of a list are located in a screenshot.
+type RowLocator int
+
+const (
+	RowLocatorButtons           RowLocator = iota // Rows are located by their Follow/Following/Message buttons
+	RowLocatorAvatars                             // Rows are located by their circular profile pictures
+	RowLocatorButtonsAndAvatars                   // Rows are located by either of them
+)
+
+// Binarization selects how crops are turned into black and white before OCR.
+type Binarization int
+
+const (
+	BinarizationNone     Binarization = iota // Crops are left in grayscale, Tesseract binarizes them itself
+	BinarizationOtsu                         // A single threshold per crop, chosen with Otsu's method
+	BinarizationAdaptive                     // A threshold per pixel, from a Gaussian weighted neighborhood
+)
+
 type SamplePosition struct {
 	ReferencePoint        image.Point     // Min point of a rectangle that surrounds a button
 	CenterUsernameRect    image.Rectangle // Center username rectangle relative to reference point
 	TopCenterUsernameRect image.Rectangle // Top Center username rectangle relative to reference point
 	UpUsernameRect        image.Rectangle // Up username rectangle relative to reference point
+	AvatarRect            image.Rectangle // Profile picture bounding rectangle relative to reference point
+	TextRect              image.Rectangle // Area between the profile picture and the button holding the row text lines, relative to reference point
 }
 
 type Config struct {
-	WorkingDirPath             string                 // Path tp directory when temp files will be created
-	ReferencePointsSearchRect  image.Rectangle        // Area where reference points are allowed to be in (changes according device/fontsize where image was captured)
-	ReferencePointsXCoordinate int                    // X coordinate of reference points
-	GroupAveragesThreshold     int                    // Maximum difference between two consecutive numbers for them to belong to the same group
-	MatchTemplateThreshold     float32                // Minimum similarity threshold for a match to be considered valid
-	MatchTemplateMethod        gocv.TemplateMatchMode // Template matching method (e.g., SQDIFF, CCORR)
-	MatchTemplateImageFlags    gocv.IMReadFlag        // Flags used to read screenshot and template images used to match template
-	OcrImageFlags              gocv.IMReadFlag        // Flags used to read screenshot used to crop usernames images used in ocr
-	UniformThresold            int                    // Maximum difference threshold between pixel values for them to be considered equal
-	SamplePosition             SamplePosition         // Sample of a reference point ant 3 rectangles, that will be used to define base rectangles
-	TesseractOcrOem            int                    // Tesseract OCR engine mode (OEM) to use for text recognition
-	TesseractOcrPsm            int                    // Tesseract OCR page segmentation mode (PSM) to use for text recognition
-	TesseractOcrConfigs        map[string]string      // Additional Tesseract OCR configuration key-value pairs
+	WorkingDirPath                   string                 // Path tp directory when temp files will be created
+	FrameDirPath                     string                 // Path to directory with the frames of the batch (e.g. a screen recording split into PNG images), processed in name order
+	TemplatePackDirPath              string                 // Path to the template pack directory (e.g. template/pt_BR), whose manifest lists the button templates
+	ReferencePointsSearchRect        image.Rectangle        // Area where reference points are allowed to be in (changes according device/fontsize where image was captured)
+	ReferencePointsXCoordinate       int                    // X coordinate of reference points
+	GroupAveragesThreshold           int                    // Maximum difference between two consecutive numbers for them to belong to the same group
+	MatchTemplateThreshold           float32                // Minimum similarity threshold for a match to be considered valid
+	MatchTemplateMethod              gocv.TemplateMatchMode // Template matching method (e.g., SQDIFF, CCORR)
+	MatchTemplateImageFlags          gocv.IMReadFlag        // Flags used to read screenshot and template images used to match template
+	OcrImageFlags                    gocv.IMReadFlag        // Flags used to read screenshot used to crop usernames images used in ocr
+	UniformThresold                  int                    // Maximum difference threshold between pixel values for them to be considered equal
+	SamplePosition                   SamplePosition         // Sample of a reference point ant 3 rectangles, that will be used to define base rectangles
+	TesseractOcrOem                  int                    // Tesseract OCR engine mode (OEM) to use for text recognition
+	TesseractOcrPsm                  int                    // Tesseract OCR page segmentation mode (PSM) to use for text recognition
+	TesseractOcrConfigs              map[string]string      // Additional Tesseract OCR configuration key-value pairs
+	TesseractOcrDisplayNameLang      string                 // Tesseract language set used to read display names (e.g. por+eng), which may hold any Unicode letter
+	BatchOcr                         bool                   // Whether to read all usernames (and all display names) of a screenshot with a single OCR call instead of one per row
+	TesseractOcrBatchPsm             int                    // Tesseract page segmentation mode used to read the row crops stacked by a batch OCR call
+	TesseractOcrBatchSpacing         int                    // Margin, in pixels, added around each row crop stacked by a batch OCR call so rows are read as separate lines
+	OcrMinBatchSize                  int                    // Minimum number of rows read by each batch OCR call, so OcrConcurrency does not split a screenshot into one call per row
+	OcrConcurrency                   int                    // Maximum number of OCR calls of a screenshot running at the same time (rows, or batches of rows with BatchOcr), one at a time when below 2
+	FrameConcurrency                 int                    // Maximum number of frames extracted at the same time, one at a time when below 2
+	OcrTimeout                       time.Duration          // Maximum duration of a single OCR call (one tesseract process), after which it is killed and fails, no limit when 0
+	UsernameReviewConfidence         float64                // Minimum OCR confidence (0-100) of every word of a username for it to be trusted, lower ones are flagged for review; nothing is flagged when 0
+	ValidateUsernames                bool                   // Whether to check usernames read by OCR against the Instagram username grammar, correcting or flagging those that break it
+	OcrPreprocessUpscale             bool                   // Whether to enlarge username crops before OCR
+	OcrPreprocessUpscaleFactor       float64                // Factor username crops are enlarged by, with bicubic interpolation
+	OcrPreprocessInvertDark          bool                   // Whether to invert username crops with a dark background, so text is dark on light as Tesseract expects
+	OcrPreprocessSharpen             bool                   // Whether to sharpen username crops with an unsharp mask
+	OcrPreprocessSharpenAmount       float64                // Strength of the unsharp mask, the weight of the removed blur (e.g. 1 doubles the edge contrast)
+	OcrPreprocessBinarization        Binarization           // How username crops are binarized before OCR, none by default
+	OcrPreprocessAdaptiveBlockSize   int                    // Size, in pixels, of the neighborhood of adaptive binarization, odd and greater than 1
+	OcrPreprocessAdaptiveC           float32                // Constant subtracted from the neighborhood mean by adaptive binarization
+	OcrPreprocessPad                 bool                   // Whether to add a margin of background around username crops, Tesseract reads glyphs touching the edge poorly
+	OcrPreprocessPadding             int                    // Size, in pixels of the preprocessed crop, of the margin added around username crops
+	DriftNearMissMargin              float32                // Maximum distance below MatchTemplateThreshold for a best match score to count as a near miss
+	DriftRowDropRatio                float64                // Fraction of the usual number of rows per frame below which a frame counts as a sudden drop
+	DriftMinFrames                   int                    // Minimum number of previous frames needed before row drops are detected
+	InferMissingRows                 bool                   // Whether to insert reference points for rows without a matched button, based on the row spacing (gaps shorter than SamplePosition.AvatarRect are ignored)
+	RowSpacingTolerance              int                    // Maximum difference between a gap and a multiple of the row spacing for rows to be inferred in it, and between a gap and the median gap for it to count toward the spacing
+	RowLocator                       RowLocator             // How rows are located: by buttons (default), by profile pictures or by both
+	AvatarSearchRect                 image.Rectangle        // Area where profile picture centers are allowed to be in
+	AvatarMinRadius                  int                    // Minimum radius of a profile picture
+	AvatarMaxRadius                  int                    // Maximum radius of a profile picture
+	AvatarHoughThreshold             float64                // Accumulator threshold of the circle detection, lower values find more (and falser) circles
+	AutoEstimateReferencePoints      bool                   // Whether to estimate ReferencePointsXCoordinate and ReferencePointsSearchRect from each screenshot's button matches
+	ReferencePointsEstimateTolerance int                    // Maximum distance from the estimated X coordinate for a match to be part of the list (also the search rect margin)
+	RowLayoutInkThreshold            int                    // Minimum difference between a pixel gray value and the row background for it to be text
+	RowLayoutMinLineHeight           int                    // Minimum height of a text line, shorter lines are noise
+	RowLayoutMaxLineGap              int                    // Maximum gap between two parts of the same text line (e.g. accents above letters)
+	RowLayoutLinePadding             int                    // Margin added around a text line bounding box before OCR
+	RefineUsernameRect               bool                   // Whether to shrink or grow username rects to the extent of the username text before OCR
+	UsernameInkThreshold             int                    // Minimum difference between a pixel gray value and the background for it to be part of the username text
+	UsernameMinComponentArea         int                    // Minimum number of pixels of a connected component for it to be part of the username text, smaller ones are noise
+	UsernameMaxCharGap               int                    // Maximum gap between two characters of a username, wider gaps end it (e.g. before the verified badge)
+	UsernameRectPadding              int                    // Margin added around a refined username rect before OCR
+	UsernameTruncationEdgeMargin     int                    // Maximum distance between a refined username and the button for it to be considered cut off
+	BadgeMinSaturation               int                    // Minimum saturation (0-255) of a pixel for it to be part of a colored symbol such as the verified badge
+	DetectRowAttributes              bool                   // Whether to detect the verified badge and the story ring of each row (needs a color MatchTemplateImageFlags, skipped with a warning otherwise)
+	BadgeSearchWidth                 int                    // Width of the area right of a refined username where the verified badge is searched
+	BadgeMinPixels                   int                    // Minimum number of badge blue pixels for an account to be verified
+	StoryRingWidth                   int                    // Width of the outer band of the profile picture circle where the story ring is searched
+	StoryRingMinSaturation           int                    // Minimum saturation (0-255) of a pixel for it to be part of a story ring
+	StoryRingMinRatio                float64                // Minimum fraction of saturated pixels in the outer band of the profile picture for it to have a story ring
+	HashAvatars                      bool                   // Whether to compute a perceptual hash of each row's profile picture
+	AvatarCropDirPath                string                 // Path to directory where profile picture crops are kept for review (not cleaned between runs), no crops are saved when empty
+	RenameMaxAvatarDistance          int                    // Maximum distance between the avatar hashes of a disappeared and an appeared account for them to be a probable rename
+	AccountSnapshotPath              string                 // Path of the file where the accounts of the last run are kept, compared against the next run to find renames
+	ListTopReferenceY                int                    // Y of the reference point of the first row when the list is scrolled to the top (give or take RowSpacingTolerance), 0 to take every capture as started at the top
+	DetectSuggestionsHeader          bool                   // Whether to look for the "Suggested for you" header, which ends the list
+	SuggestionsHeaderPhrases         []string               // Text of the "Suggested for you" header in each app language (e.g. "Sugestões para você")
+	SuggestionsHeaderSearchRect      image.Rectangle        // Area of the screenshot where the "Suggested for you" header is searched (the list area), only its text lines between rows are read
+	DetectOcclusions                 bool                   // Whether to find overlays over the list (banners, search bar, keyboard, toasts) and mask them out
+	OcclusionInkThreshold            int                    // Minimum difference between a pixel gray value and the list background for it to be part of an overlay
+	OcclusionMinWidth                int                    // Minimum width of an overlay, wider than any button or profile picture
+	OcclusionMinHeight               int                    // Minimum height of an overlay
+	OcclusionMinFillRatio            float64                // Minimum fraction of its bounding box an overlay fills, text lines fill less
+	DetectSpinner                    bool                   // Whether to look for the loading spinner below the last row
+	SpinnerSearchRect                image.Rectangle        // Area of the screenshot where the loading spinner is searched (the bottom of the list)
+	SpinnerInkThreshold              int                    // Minimum difference between a pixel gray value and the background for it to be part of a spinner spoke
+	SpinnerMaxSpokeLength            int                    // Maximum width and height of a spinner spoke
+	SpinnerMinSpokes                 int                    // Minimum number of spokes found for a spinner, faint spokes may be missed
+	SpinnerMinRadius                 int                    // Minimum distance between the spinner center and the spoke centers
+	SpinnerMaxRadius                 int                    // Maximum distance between the spinner center and the spoke centers
+	SpinnerRadiusTolerance           float64                // Maximum distance of a spoke center to the fitted spinner circle, as a fraction of its radius
 }
@@ -0,0 +1,291 @@
+package driftdetector
+
+import (
+	"fmt"
+	"image"
+	"path/filepath"
+	"sort"
+
+	"github.com/palantir/stacktrace"
+	"github.com/rogeriofbrito/go-insta-scraper-v2/config"
+	"github.com/rogeriofbrito/go-insta-scraper-v2/templatematcher"
+	"github.com/rogeriofbrito/go-insta-scraper-v2/util"
+	"gocv.io/x/gocv"
+)
+
+// NewDriftDetector creates a new DriftDetector using the matching threshold and drift parameters of config.
+func NewDriftDetector(config *config.Config) *DriftDetector {
+	return &DriftDetector{
+		config: config,
+	}
+}
+
+// DriftDetector watches the template matching statistics of a batch of frames and detects
+// templates that probably went stale after an app update changed the look of the buttons.
+type DriftDetector struct {
+	config       *config.Config
+	observations []observation
+}
+
+// minRowDrops is the number of frames whose rows must drop for the templates to be stale: a single frame
+// can lose its rows for other reasons (e.g. a transition between screens).
+const minRowDrops = 2
+
+// Frame is what a single frame tells about the templates.
+type Frame struct {
+	Stats       map[string]templatematcher.MatchStats // Best match of each template, keyed by template name
+	RowCount    int                                   // Number of rows extracted from the frame
+	MissingRows int                                   // Number of rows without a matched button (e.g. inferred from the row spacing)
+	Occluded    bool                                  // Whether an overlay was masked out of the frame, so rows under it are missing
+}
+
+type observation struct {
+	name  string
+	frame Frame
+}
+
+// ScoreDistribution summarizes the best match scores of a template across the frames of a batch.
+type ScoreDistribution struct {
+	Count      int     // Number of frames observed
+	Min        float32 // Lowest best score
+	P25        float32 // First quartile of the best scores
+	Median     float32 // Median of the best scores
+	P75        float32 // Third quartile of the best scores
+	Max        float32 // Highest best score
+	NearMisses int     // Number of frames whose best score was just below the matching threshold
+}
+
+// Candidate is the region of a frame that best matched a template without reaching the threshold.
+// Cropping it gives a starting point for a new template.
+type Candidate struct {
+	Frame string          // Frame where the region was found
+	Rect  image.Rectangle // Region of the frame
+	Score float32         // Best match score of the region
+}
+
+// RowDrop describes a frame with far fewer rows than the frames before it.
+type RowDrop struct {
+	Frame            string // Frame where the drop happened
+	RowCount         int    // Number of rows found in the frame
+	ExpectedRowCount int    // Median number of rows of the previous frames
+}
+
+// Report is the result of the drift analysis of a batch.
+type Report struct {
+	Stale      bool                         // Whether the templates are probably stale
+	Warnings   []string                     // Human readable description of every drift found
+	Scores     map[string]ScoreDistribution // Best score distribution over every frame, keyed by template name
+	Suspects   map[string]ScoreDistribution // Best score distribution over the frames missing rows, keyed by template name
+	Candidates map[string]Candidate         // Best region below the threshold, keyed by template name (only when Stale)
+	RowDrops   []RowDrop                    // Frames with a sudden drop in the number of rows
+}
+
+// Observe records what one frame of the batch tells about the templates. Frames must be observed in capture
+// order for row drops to be detected.
+func (d *DriftDetector) Observe(name string, frame Frame) {
+	d.observations = append(d.observations, observation{
+		name:  name,
+		frame: frame,
+	})
+}
+
+// Report analyzes every frame observed so far.
+// A template is considered stale when its median best score, over the frames missing rows, falls within
+// DriftNearMissMargin below MatchTemplateThreshold: a template that is absent from the list (e.g. "message"
+// on a followers list) also scores just below the threshold, but on frames whose rows all matched a button.
+// The whole batch is considered stale when the number of rows of at least minRowDrops frames drops below
+// DriftRowDropRatio of the median of the previous frames. Occluded frames are left out of both.
+func (d *DriftDetector) Report() Report {
+	report := Report{
+		Scores:     map[string]ScoreDistribution{},
+		Suspects:   map[string]ScoreDistribution{},
+		Candidates: map[string]Candidate{},
+	}
+
+	threshold := d.config.MatchTemplateThreshold
+	for _, templateName := range d.getTemplateNames() {
+		report.Scores[templateName] = d.getScoreDistribution(templateName, func(observation) bool { return true })
+		distribution := d.getScoreDistribution(templateName, isSuspect)
+		report.Suspects[templateName] = distribution
+
+		if distribution.Count > 0 && d.isNearMiss(distribution.Median) {
+			report.Stale = true
+			report.Warnings = append(report.Warnings, fmt.Sprintf(
+				"template %q is probably stale: median best score %.3f over the frames missing rows is just below threshold %.3f "+
+					"(min %.3f, p25 %.3f, p75 %.3f, max %.3f, %d of %d frames near miss)",
+				templateName, distribution.Median, threshold,
+				distribution.Min, distribution.P25, distribution.P75, distribution.Max,
+				distribution.NearMisses, distribution.Count,
+			))
+		}
+	}
+
+	report.RowDrops = d.getRowDrops()
+	if len(report.RowDrops) >= minRowDrops {
+		report.Stale = true
+		for _, rowDrop := range report.RowDrops {
+			report.Warnings = append(report.Warnings, fmt.Sprintf(
+				"templates are probably stale: frame %s has %d rows, expected about %d",
+				rowDrop.Frame, rowDrop.RowCount, rowDrop.ExpectedRowCount,
+			))
+		}
+	}
+
+	if report.Stale {
+		for _, templateName := range d.getTemplateNames() {
+			candidate, found := d.getCandidate(templateName)
+			if found {
+				report.Candidates[templateName] = candidate
+			}
+		}
+	}
+
+	return report
+}
+
+// WriteCandidateTemplates crops the candidate regions of the report from their frames and writes
+// them into dirPath as <template name>_candidate.png, so they can be reviewed and used as new templates.
+// Returns the paths of the written images.
+func WriteCandidateTemplates(report Report, dirPath string) ([]string, error) {
+	var templateNames []string
+	for templateName := range report.Candidates {
+		templateNames = append(templateNames, templateName)
+	}
+	sort.Strings(templateNames)
+
+	var candidatePaths []string
+	for _, templateName := range templateNames {
+		candidate := report.Candidates[templateName]
+
+		frameMat := gocv.IMRead(candidate.Frame, gocv.IMReadColor)
+		if frameMat.Empty() {
+			return nil, stacktrace.NewError("failed to read frame at %s: image empty", candidate.Frame)
+		}
+
+		rect := candidate.Rect.Intersect(image.Rect(0, 0, frameMat.Cols(), frameMat.Rows()))
+		candidateMat := frameMat.Region(rect)
+		candidatePath := filepath.Join(dirPath, fmt.Sprintf("%s_candidate.png", templateName))
+
+		writeSuccess := gocv.IMWrite(candidatePath, candidateMat)
+		candidateMat.Close()
+		frameMat.Close()
+		if !writeSuccess {
+			return nil, stacktrace.NewError("failed to write mat at path %s", candidatePath)
+		}
+
+		candidatePaths = append(candidatePaths, candidatePath)
+	}
+
+	return candidatePaths, nil
+}
+
+// getTemplateNames returns the sorted names of every template observed.
+func (d *DriftDetector) getTemplateNames() []string {
+	seen := map[string]bool{}
+	var templateNames []string
+	for _, obs := range d.observations {
+		for templateName := range obs.frame.Stats {
+			if !seen[templateName] {
+				seen[templateName] = true
+				templateNames = append(templateNames, templateName)
+			}
+		}
+	}
+	sort.Strings(templateNames)
+
+	return templateNames
+}
+
+// getScoreDistribution summarizes the best scores of a template across the observed frames for which
+// include returns true.
+func (d *DriftDetector) getScoreDistribution(templateName string, include func(obs observation) bool) ScoreDistribution {
+	var scores []float64
+	nearMisses := 0
+	for _, obs := range d.observations {
+		stats, found := obs.frame.Stats[templateName]
+		if !found || !include(obs) {
+			continue
+		}
+
+		scores = append(scores, float64(stats.BestScore))
+		if d.isNearMiss(stats.BestScore) {
+			nearMisses++
+		}
+	}
+
+	if len(scores) == 0 {
+		return ScoreDistribution{}
+	}
+
+	return ScoreDistribution{
+		Count:      len(scores),
+		Min:        float32(util.Percentile(scores, 0)),
+		P25:        float32(util.Percentile(scores, 25)),
+		Median:     float32(util.Median(scores)),
+		P75:        float32(util.Percentile(scores, 75)),
+		Max:        float32(util.Percentile(scores, 100)),
+		NearMisses: nearMisses,
+	}
+}
+
+// getRowDrops returns the frames whose number of rows dropped below DriftRowDropRatio
+// of the median number of rows of the frames before them. Occluded frames are skipped.
+func (d *DriftDetector) getRowDrops() []RowDrop {
+	var rowDrops []RowDrop
+	var previousRowCounts []float64
+	for _, obs := range d.observations {
+		if obs.frame.Occluded {
+			continue
+		}
+
+		if len(previousRowCounts) >= d.config.DriftMinFrames && len(previousRowCounts) > 0 {
+			expectedRowCount := util.Median(previousRowCounts)
+			if float64(obs.frame.RowCount) < expectedRowCount*d.config.DriftRowDropRatio {
+				rowDrops = append(rowDrops, RowDrop{
+					Frame:            obs.name,
+					RowCount:         obs.frame.RowCount,
+					ExpectedRowCount: int(expectedRowCount),
+				})
+			}
+		}
+
+		previousRowCounts = append(previousRowCounts, float64(obs.frame.RowCount))
+	}
+
+	return rowDrops
+}
+
+// getCandidate returns the highest scoring region of a template that did not reach the threshold.
+func (d *DriftDetector) getCandidate(templateName string) (Candidate, bool) {
+	var best Candidate
+	found := false
+	for _, obs := range d.observations {
+		stats, ok := obs.frame.Stats[templateName]
+		if !ok || stats.BestScore >= d.config.MatchTemplateThreshold {
+			continue
+		}
+
+		if !found || stats.BestScore > best.Score {
+			best = Candidate{
+				Frame: obs.name,
+				Rect:  stats.BestRect,
+				Score: stats.BestScore,
+			}
+			found = true
+		}
+	}
+
+	return best, found
+}
+
+// isSuspect reports whether a frame may hide rows whose button no template matched: some of its rows have
+// no matched button, or it has no rows at all, and no overlay explains it.
+func isSuspect(obs observation) bool {
+	return !obs.frame.Occluded && (obs.frame.MissingRows > 0 || obs.frame.RowCount == 0)
+}
+
+// isNearMiss checks whether a score is below the matching threshold by at most DriftNearMissMargin.
+func (d *DriftDetector) isNearMiss(score float32) bool {
+	threshold := d.config.MatchTemplateThreshold
+	return score < threshold && score >= threshold-d.config.DriftNearMissMargin
+}
@@ -0,0 +1,198 @@
+package driftdetector_test
+
+import (
+	"image"
+	"testing"
+
+	"github.com/rogeriofbrito/go-insta-scraper-v2/config"
+	"github.com/rogeriofbrito/go-insta-scraper-v2/driftdetector"
+	"github.com/rogeriofbrito/go-insta-scraper-v2/templatematcher"
+)
+
+type frame struct {
+	name        string
+	scores      map[string]float32
+	rowCount    int
+	missingRows int
+	occluded    bool
+}
+
+func TestDriftDetector_Report_DiverseCases(t *testing.T) {
+	cfg := config.Config{
+		MatchTemplateThreshold: 0.8,
+		DriftNearMissMargin:    0.1,
+		DriftRowDropRatio:      0.5,
+		DriftMinFrames:         3,
+	}
+
+	tests := []struct {
+		name               string
+		frames             []frame
+		expectedStale      bool
+		expectedCandidates map[string]string // template name -> frame of the candidate
+		expectedRowDrops   []string          // frames with row drops
+	}{
+		{
+			name:               "no_frames_is_not_stale",
+			frames:             nil,
+			expectedStale:      false,
+			expectedCandidates: map[string]string{},
+		},
+		{
+			name: "matching_templates_are_not_stale",
+			frames: []frame{
+				{name: "frame_1", scores: map[string]float32{"follow": 0.95, "following": 0.93}, rowCount: 9},
+				{name: "frame_2", scores: map[string]float32{"follow": 0.96, "following": 0.91}, rowCount: 9},
+			},
+			expectedStale:      false,
+			expectedCandidates: map[string]string{},
+		},
+		{
+			name: "absent_template_is_not_stale",
+			frames: []frame{
+				{name: "frame_1", scores: map[string]float32{"follow": 0.95, "message": 0.31}, rowCount: 9},
+				{name: "frame_2", scores: map[string]float32{"follow": 0.96, "message": 0.35}, rowCount: 9},
+			},
+			expectedStale:      false,
+			expectedCandidates: map[string]string{},
+		},
+		{
+			name: "scores_just_below_threshold_on_frames_missing_rows_are_stale",
+			frames: []frame{
+				{name: "frame_1", scores: map[string]float32{"follow": 0.74, "following": 0.95}, rowCount: 9, missingRows: 7},
+				{name: "frame_2", scores: map[string]float32{"follow": 0.78, "following": 0.94}, rowCount: 9, missingRows: 6},
+				{name: "frame_3", scores: map[string]float32{"follow": 0.76, "following": 0.93}, rowCount: 9, missingRows: 7},
+			},
+			expectedStale:      true,
+			expectedCandidates: map[string]string{"follow": "frame_2"},
+		},
+		{
+			name: "absent_template_near_miss_is_not_stale",
+			frames: []frame{
+				{name: "frame_1", scores: map[string]float32{"follow": 0.95, "message": 0.76}, rowCount: 9},
+				{name: "frame_2", scores: map[string]float32{"follow": 0.96, "message": 0.78}, rowCount: 9},
+				{name: "frame_3", scores: map[string]float32{"follow": 0.95, "message": 0.77}, rowCount: 9},
+			},
+			expectedStale:      false,
+			expectedCandidates: map[string]string{},
+		},
+		{
+			name: "near_misses_on_occluded_frames_are_not_stale",
+			frames: []frame{
+				{name: "frame_1", scores: map[string]float32{"follow": 0.74}, rowCount: 9, missingRows: 4, occluded: true},
+				{name: "frame_2", scores: map[string]float32{"follow": 0.78}, rowCount: 9, missingRows: 4, occluded: true},
+			},
+			expectedStale:      false,
+			expectedCandidates: map[string]string{},
+		},
+		{
+			name: "sudden_row_drops_are_stale",
+			frames: []frame{
+				{name: "frame_1", scores: map[string]float32{"follow": 0.95}, rowCount: 9},
+				{name: "frame_2", scores: map[string]float32{"follow": 0.95}, rowCount: 8},
+				{name: "frame_3", scores: map[string]float32{"follow": 0.94}, rowCount: 9},
+				{name: "frame_4", scores: map[string]float32{"follow": 0.61}, rowCount: 2},
+				{name: "frame_5", scores: map[string]float32{"follow": 0.62}, rowCount: 3},
+			},
+			expectedStale:      true,
+			expectedCandidates: map[string]string{"follow": "frame_5"},
+			expectedRowDrops:   []string{"frame_4", "frame_5"},
+		},
+		{
+			name: "single_row_drop_is_not_stale",
+			frames: []frame{
+				{name: "frame_1", scores: map[string]float32{"follow": 0.95}, rowCount: 9},
+				{name: "frame_2", scores: map[string]float32{"follow": 0.95}, rowCount: 8},
+				{name: "frame_3", scores: map[string]float32{"follow": 0.94}, rowCount: 9},
+				{name: "frame_4", scores: map[string]float32{"follow": 0.61}, rowCount: 2},
+				{name: "frame_5", scores: map[string]float32{"follow": 0.95}, rowCount: 9},
+			},
+			expectedStale:      false,
+			expectedCandidates: map[string]string{},
+			expectedRowDrops:   []string{"frame_4"},
+		},
+		{
+			name: "occluded_frames_are_not_row_drops",
+			frames: []frame{
+				{name: "frame_1", scores: map[string]float32{"follow": 0.95}, rowCount: 9},
+				{name: "frame_2", scores: map[string]float32{"follow": 0.95}, rowCount: 8},
+				{name: "frame_3", scores: map[string]float32{"follow": 0.94}, rowCount: 9},
+				{name: "frame_4", scores: map[string]float32{"follow": 0.95}, rowCount: 2, occluded: true},
+				{name: "frame_5", scores: map[string]float32{"follow": 0.95}, rowCount: 3, occluded: true},
+			},
+			expectedStale:      false,
+			expectedCandidates: map[string]string{},
+		},
+		{
+			name: "row_drop_needs_min_frames",
+			frames: []frame{
+				{name: "frame_1", scores: map[string]float32{"follow": 0.95}, rowCount: 9},
+				{name: "frame_2", scores: map[string]float32{"follow": 0.95}, rowCount: 2},
+			},
+			expectedStale:      false,
+			expectedCandidates: map[string]string{},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			detector := driftdetector.NewDriftDetector(&cfg)
+			for _, f := range tc.frames {
+				stats := map[string]templatematcher.MatchStats{}
+				for templateName, score := range f.scores {
+					stats[templateName] = templatematcher.MatchStats{BestScore: score, BestRect: image.Rect(0, 0, 10, 10)}
+				}
+				detector.Observe(f.name, driftdetector.Frame{
+					Stats:       stats,
+					RowCount:    f.rowCount,
+					MissingRows: f.missingRows,
+					Occluded:    f.occluded,
+				})
+			}
+
+			report := detector.Report()
+			if report.Stale != tc.expectedStale {
+				t.Fatalf("Report().Stale = %v; expected %v (warnings: %v)", report.Stale, tc.expectedStale, report.Warnings)
+			}
+			if report.Stale && len(report.Warnings) == 0 {
+				t.Errorf("Report() is stale but has no warnings")
+			}
+			if len(report.Candidates) != len(tc.expectedCandidates) {
+				t.Fatalf("Report().Candidates = %v; expected %v", report.Candidates, tc.expectedCandidates)
+			}
+			for templateName, expectedFrame := range tc.expectedCandidates {
+				if report.Candidates[templateName].Frame != expectedFrame {
+					t.Errorf("Report().Candidates[%q].Frame = %q; expected %q",
+						templateName, report.Candidates[templateName].Frame, expectedFrame)
+				}
+			}
+			if len(report.RowDrops) != len(tc.expectedRowDrops) {
+				t.Fatalf("Report().RowDrops = %v; expected frames %v", report.RowDrops, tc.expectedRowDrops)
+			}
+			for i, expectedFrame := range tc.expectedRowDrops {
+				if report.RowDrops[i].Frame != expectedFrame {
+					t.Errorf("Report().RowDrops[%d].Frame = %q; expected %q", i, report.RowDrops[i].Frame, expectedFrame)
+				}
+			}
+		})
+	}
+}
+
+func TestDriftDetector_Report_ScoreDistribution(t *testing.T) {
+	cfg := config.Config{MatchTemplateThreshold: 0.8, DriftNearMissMargin: 0.1}
+	detector := driftdetector.NewDriftDetector(&cfg)
+	for _, score := range []float32{0.5, 0.75, 0.85, 0.9, 0.95} {
+		detector.Observe("frame", driftdetector.Frame{Stats: map[string]templatematcher.MatchStats{"follow": {BestScore: score}}, RowCount: 9})
+	}
+
+	distribution := detector.Report().Scores["follow"]
+	if distribution.Count != 5 {
+		t.Errorf("Count = %d; expected 5", distribution.Count)
+	}
+	if distribution.Min != 0.5 || distribution.Max != 0.95 || distribution.Median != 0.85 {
+		t.Errorf("Min, Median, Max = %v, %v, %v; expected 0.5, 0.85, 0.95", distribution.Min, distribution.Median, distribution.Max)
+	}
+	if distribution.NearMisses != 1 {
+		t.Errorf("NearMisses = %d; expected 1", distribution.NearMisses)
+	}
+}
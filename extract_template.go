@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"image"
+	"log"
+
+	"github.com/palantir/stacktrace"
+	"github.com/rogeriofbrito/go-insta-scraper-v2/config"
+	"github.com/rogeriofbrito/go-insta-scraper-v2/templateextractor"
+	"github.com/rogeriofbrito/go-insta-scraper-v2/tesseractocr"
+)
+
+// runExtractTemplate implements the extract-template command, which crops a button template from a
+// screenshot, given either its rectangle or its label text, and writes it into a template pack.
+//
+// Usage:
+//
+//	go-insta-scraper-v2 extract-template -screenshot frame.png -label Seguir -name follow [-pack ./template/pt_BR]
+//	go-insta-scraper-v2 extract-template -screenshot frame.png -rect 629,482,840,553 -name follow [-pack ./template/pt_BR]
+func runExtractTemplate(ctx context.Context, config *config.Config, args []string) error {
+	flags := flag.NewFlagSet("extract-template", flag.ContinueOnError)
+	screenshotPath := flags.String("screenshot", "", "path of the screenshot to crop the template from")
+	rectArg := flags.String("rect", "", "button region of the screenshot as x0,y0,x1,y1")
+	label := flags.String("label", "", "button label used to locate the button with OCR (e.g. Seguir)")
+	packDirPath := flags.String("pack", config.TemplatePackDirPath, "template pack directory")
+	templateName := flags.String("name", "", "template name (e.g. follow, following, message)")
+
+	err := flags.Parse(args)
+	if err != nil {
+		return stacktrace.Propagate(err, "failed to parse extract-template arguments")
+	}
+
+	if *screenshotPath == "" || *templateName == "" {
+		return stacktrace.NewError("extract-template requires -screenshot and -name")
+	}
+	if (*rectArg == "") == (*label == "") {
+		return stacktrace.NewError("extract-template requires either -rect or -label")
+	}
+
+	te := templateextractor.NewTemplateExtractor(config, tesseractocr.NewTemplateLabelTesseractOcr(config))
+
+	var templatePath string
+	if *rectArg != "" {
+		var x0, y0, x1, y1 int
+		_, err = fmt.Sscanf(*rectArg, "%d,%d,%d,%d", &x0, &y0, &x1, &y1)
+		if err != nil {
+			return stacktrace.Propagate(err, "failed to parse rect %s, expected x0,y0,x1,y1", *rectArg)
+		}
+
+		templatePath, err = te.ExtractFromRect(*screenshotPath, image.Rect(x0, y0, x1, y1), *packDirPath, *templateName)
+	} else {
+		templatePath, err = te.ExtractFromLabel(ctx, *screenshotPath, *label, *packDirPath, *templateName)
+	}
+	if err != nil {
+		return stacktrace.Propagate(err, "failed to extract template %s", *templateName)
+	}
+
+	log.Printf("template %s written to %s", *templateName, templatePath)
+
+	return nil
+}
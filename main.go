@@ -1,12 +1,27 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"image"
+	"log"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"sync"
+	"syscall"
+	"time"
 
+	"github.com/palantir/stacktrace"
+	"github.com/rogeriofbrito/go-insta-scraper-v2/accountdiff"
+	"github.com/rogeriofbrito/go-insta-scraper-v2/capturemonitor"
 	"github.com/rogeriofbrito/go-insta-scraper-v2/config"
+	"github.com/rogeriofbrito/go-insta-scraper-v2/driftdetector"
 	"github.com/rogeriofbrito/go-insta-scraper-v2/screenshotuserextractor"
-	"github.com/rogeriofbrito/go-insta-scraper-v2/templatematcher"
-	"github.com/rogeriofbrito/go-insta-scraper-v2/tesseractocr"
+	"github.com/rogeriofbrito/go-insta-scraper-v2/templatepack"
+	"github.com/rogeriofbrito/go-insta-scraper-v2/usernamegrammar"
 	"github.com/rogeriofbrito/go-insta-scraper-v2/util"
 	"gocv.io/x/gocv"
 )
@@ -15,6 +30,8 @@ func main() {
 	// TODO: add function to create config.Config from env vars
 	config := &config.Config{
 		WorkingDirPath:             "/tmp/go-insta-scraper",
+		FrameDirPath:               "./frame",
+		TemplatePackDirPath:        "./template/pt_BR",
 		ReferencePointsSearchRect:  image.Rect(600, 308, 675, 1690),
 		ReferencePointsXCoordinate: 629,
 		GroupAveragesThreshold:     10,
@@ -28,6 +45,8 @@ func main() {
 			TopCenterUsernameRect: image.Rect(165, 482, 165+440, 482+36),
 			CenterUsernameRect:    image.Rect(165, 518, 165+440, 518+36),
 			UpUsernameRect:        image.Rect(165, 498, 165+440, 498+36),
+			AvatarRect:            image.Rect(25, 469, 25+132, 469+132),
+			TextRect:              image.Rect(165, 461, 165+440, 461+150),
 		},
 		TesseractOcrOem: 1,
 		TesseractOcrPsm: 7,
@@ -35,28 +54,360 @@ func main() {
 			"tessedit_char_whitelist":   "abcdefghijklmnopqrstuvwxyz0123456789._",
 			"classify_bln_numeric_mode": "1",
 		},
+		TesseractOcrDisplayNameLang:      "por+eng",
+		BatchOcr:                         true,
+		TesseractOcrBatchPsm:             6, // uniform block of text, one line per stacked row
+		TesseractOcrBatchSpacing:         16,
+		OcrMinBatchSize:                  16,
+		OcrConcurrency:                   1,
+		FrameConcurrency:                 runtime.NumCPU(),
+		OcrTimeout:                       30 * time.Second,
+		UsernameReviewConfidence:         70,
+		ValidateUsernames:                true,
+		OcrPreprocessUpscale:             true,
+		OcrPreprocessUpscaleFactor:       2,
+		OcrPreprocessInvertDark:          true,
+		OcrPreprocessSharpen:             false,
+		OcrPreprocessSharpenAmount:       1,
+		OcrPreprocessBinarization:        config.BinarizationNone,
+		OcrPreprocessAdaptiveBlockSize:   31,
+		OcrPreprocessAdaptiveC:           10,
+		OcrPreprocessPad:                 true,
+		OcrPreprocessPadding:             10,
+		DriftNearMissMargin:              0.1,
+		DriftRowDropRatio:                0.5,
+		DriftMinFrames:                   3,
+		InferMissingRows:                 true,
+		RowSpacingTolerance:              10,
+		RowLocator:                       config.RowLocatorButtons,
+		AvatarSearchRect:                 image.Rect(0, 308, 200, 1690),
+		AvatarMinRadius:                  50,
+		AvatarMaxRadius:                  70,
+		AvatarHoughThreshold:             40,
+		AutoEstimateReferencePoints:      true,
+		ReferencePointsEstimateTolerance: 10,
+		RowLayoutInkThreshold:            40,
+		RowLayoutMinLineHeight:           8,
+		RowLayoutMaxLineGap:              3,
+		RowLayoutLinePadding:             6,
+		RefineUsernameRect:               true,
+		UsernameInkThreshold:             40,
+		UsernameMinComponentArea:         4,
+		UsernameMaxCharGap:               5,
+		UsernameTruncationEdgeMargin:     3,
+		UsernameRectPadding:              8,
+		BadgeMinSaturation:               100,
+		DetectRowAttributes:              true,
+		BadgeSearchWidth:                 50,
+		BadgeMinPixels:                   100,
+		StoryRingWidth:                   8,
+		StoryRingMinSaturation:           100,
+		StoryRingMinRatio:                0.5,
+		HashAvatars:                      true,
+		AvatarCropDirPath:                "./avatar",
+		RenameMaxAvatarDistance:          10,
+		AccountSnapshotPath:              "./snapshot.json",
+		ListTopReferenceY:                501,
+		DetectSuggestionsHeader:          true,
+		SuggestionsHeaderPhrases:         []string{"Sugestões para você", "Sugestoes para voce", "Suggested for you"},
+		SuggestionsHeaderSearchRect:      image.Rect(0, 308, 888, 1690),
+		DetectOcclusions:                 true,
+		OcclusionInkThreshold:            8,
+		OcclusionMinWidth:                300,
+		OcclusionMinHeight:               40,
+		OcclusionMinFillRatio:            0.6,
+		DetectSpinner:                    true,
+		SpinnerSearchRect:                image.Rect(0, 1200, 888, 1800),
+		SpinnerInkThreshold:              8,
+		SpinnerMaxSpokeLength:            24,
+		SpinnerMinSpokes:                 5,
+		SpinnerMinRadius:                 10,
+		SpinnerMaxRadius:                 30,
+		SpinnerRadiusTolerance:           0.25,
 	}
 
+	// Stop cleanly on SIGINT/SIGTERM: running OCR processes are killed and the extraction returns early
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
 	err := util.CreateWorkingDir(config.WorkingDirPath)
 	if err != nil {
 		panic(err)
 	}
 
-	tm := templatematcher.NewTemplateMatcher(config)
-	tocr := tesseractocr.NewTesseractOcr(config)
+	if len(os.Args) > 1 && os.Args[1] == "extract-template" {
+		err = runExtractTemplate(ctx, config, os.Args[2:])
+		if isInterrupted(err) {
+			log.Printf("interrupted, template not extracted")
+			return
+		}
+		if err != nil {
+			panic(err)
+		}
+		return
+	}
 
-	sue := screenshotuserextractor.NewScreenshotUserExtractor(
-		"./frame/frame_0056.png",         //TODO: move to config
-		"./template/pt_BR/follow.png",    //TODO: move to config
-		"./template/pt_BR/following.png", //TODO: move to config
-		"./template/pt_BR/message.png",   //TODO: move to config
-		config,
-		tm,
-		tocr,
-	)
+	framePaths, err := listFrames(config.FrameDirPath)
+	if err != nil {
+		panic(err)
+	}
 
-	_, err = sue.GetUsernames()
+	manifest, err := templatepack.LoadManifest(config.TemplatePackDirPath)
 	if err != nil {
 		panic(err)
 	}
+	templatePaths := map[string]string{}
+	for _, templateName := range []string{screenshotuserextractor.TemplateFollow, screenshotuserextractor.TemplateFollowing, screenshotuserextractor.TemplateMessage} {
+		templatePaths[templateName], err = manifest.TemplatePath(config.TemplatePackDirPath, templateName)
+		if err != nil {
+			panic(err)
+		}
+	}
+
+	deps := screenshotuserextractor.NewDependencies(config)
+	dd := driftdetector.NewDriftDetector(config)
+	cm := capturemonitor.NewCaptureMonitor()
+
+	results, err := extractFrames(ctx, framePaths, config.FrameConcurrency, func(ctx context.Context, framePath string) (*screenshotuserextractor.Result, error) {
+		sue := screenshotuserextractor.NewScreenshotUserExtractor(
+			framePath,
+			templatePaths[screenshotuserextractor.TemplateFollow],
+			templatePaths[screenshotuserextractor.TemplateFollowing],
+			templatePaths[screenshotuserextractor.TemplateMessage],
+			config,
+			deps,
+		)
+
+		return sue.Extract(ctx)
+	})
+	if isInterrupted(err) {
+		log.Printf("interrupted, frames not processed")
+		return
+	}
+	if err != nil {
+		panic(err)
+	}
+
+	var accounts, unsure []accountdiff.Account
+	for i, framePath := range framePaths {
+		result := results[i]
+		for _, warning := range result.Warnings {
+			log.Printf("warning: %s: %s", framePath, warning)
+		}
+		if result.EndOfList {
+			log.Printf("end of list reached in %s: suggested accounts start at %v", framePath, result.SuggestionsHeaderRect)
+		}
+
+		// Completeness and drift are reported once for the whole batch, they need the frames in capture order
+		cm.Observe(framePath, capturemonitor.Frame{
+			StartOfList: result.StartOfList,
+			EndOfList:   result.EndOfList,
+			Spinner:     result.Spinner,
+			Usernames:   usernamesFromRows(result.Rows),
+		})
+		dd.Observe(framePath, driftdetector.Frame{
+			Stats:       result.TemplateStats,
+			RowCount:    len(result.Rows),
+			MissingRows: countInferredRows(result.Rows),
+			Occluded:    len(result.Occlusions) > 0,
+		})
+		frameAccounts, frameUnsure := accountsFromRows(result.Rows)
+		accounts = append(accounts, frameAccounts...)
+		unsure = append(unsure, frameUnsure...)
+	}
+
+	captureReport := cm.Report()
+	for _, warning := range captureReport.Warnings {
+		log.Printf("warning: %s", warning)
+	}
+
+	// A partial capture would report every account it missed as gone, and as new again on the next run
+	switch {
+	case !captureReport.StartedAtTop:
+		log.Printf("warning: account diff skipped: the first frame %s does not show the top of the list, %s is left untouched",
+			framePaths[0], config.AccountSnapshotPath)
+	case !captureReport.ReachedEnd:
+		log.Printf("warning: account diff skipped: the capture did not reach the end of the list (no suggestions header and the last frame %s still shows new rows or the spinner), %s is left untouched",
+			framePaths[len(framePaths)-1], config.AccountSnapshotPath)
+	default:
+		err = reportAccountChanges(config, accountdiff.Dedupe(accounts), unsure)
+		if err != nil {
+			panic(err)
+		}
+	}
+
+	driftReport := dd.Report()
+	for _, warning := range driftReport.Warnings {
+		log.Printf("warning: %s", warning)
+	}
+
+	if driftReport.Stale {
+		candidatePaths, err := driftdetector.WriteCandidateTemplates(driftReport, config.WorkingDirPath)
+		if err != nil {
+			panic(err)
+		}
+
+		for _, candidatePath := range candidatePaths {
+			log.Printf("candidate template written to %s, review it and copy it into the template pack", candidatePath)
+		}
+	}
+}
+
+// listFrames returns the paths of the PNG frames in frameDirPath, sorted by name, which is the capture order
+// of numbered frames (e.g. frame_0001.png).
+func listFrames(frameDirPath string) ([]string, error) {
+	framePaths, err := filepath.Glob(filepath.Join(frameDirPath, "*.png"))
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "failed to list frames in %s", frameDirPath)
+	}
+	if len(framePaths) == 0 {
+		return nil, stacktrace.NewError("no frames found in %s", frameDirPath)
+	}
+	sort.Strings(framePaths)
+
+	return framePaths, nil
+}
+
+// extractFrames extracts every frame of framePaths with extract, with up to concurrency frames at the same
+// time (one at a time when below 2); each screenshot is read with as few OCR calls as possible, so frames are
+// what keeps the processors busy. Results are returned in the order of framePaths. Once a frame fails no more
+// frames are started and the running ones are canceled; the error of the first frame that failed on its own,
+// rather than by being canceled, is returned.
+func extractFrames(
+	ctx context.Context,
+	framePaths []string,
+	concurrency int,
+	extract func(ctx context.Context, framePath string) (*screenshotuserextractor.Result, error),
+) ([]*screenshotuserextractor.Result, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results := make([]*screenshotuserextractor.Result, len(framePaths))
+	errs := make([]error, len(framePaths))
+	slots := make(chan struct{}, max(concurrency, 1))
+	var wg sync.WaitGroup
+	for i, framePath := range framePaths {
+		slots <- struct{}{}
+		if ctx.Err() != nil {
+			errs[i] = stacktrace.Propagate(ctx.Err(), "stopped before extracting %s", framePath)
+			<-slots
+			break
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-slots }()
+
+			results[i], errs[i] = extract(ctx, framePath)
+			if errs[i] != nil {
+				errs[i] = stacktrace.Propagate(errs[i], "failed to extract %s", framePath)
+				cancel()
+			}
+		}()
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil && !isInterrupted(err) {
+			return nil, err
+		}
+	}
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return results, nil
+}
+
+// isInterrupted reports whether err was caused by the process being asked to stop.
+func isInterrupted(err error) bool {
+	return err != nil && errors.Is(stacktrace.RootCause(err), context.Canceled)
+}
+
+// reportAccountChanges logs the accounts that changed since the snapshot of the last run, recognizing
+// renamed accounts by their profile pictures, and replaces the snapshot with accounts, the whole list
+// captured by this run. The accounts of the last run that match unsure, the rows this run saw but did not
+// read confidently, are carried into the snapshot instead of being reported as gone.
+func reportAccountChanges(config *config.Config, accounts, unsure []accountdiff.Account) error {
+	previous, err := accountdiff.LoadSnapshot(config.AccountSnapshotPath)
+	if err != nil {
+		return err
+	}
+
+	ad := accountdiff.NewAccountDiffer(config)
+	current := &accountdiff.Snapshot{
+		TakenAt:  time.Now(),
+		Accounts: ad.Carry(previous.Accounts, accounts, unsure),
+	}
+
+	if carried := len(current.Accounts) - len(accounts); carried > 0 {
+		log.Printf("%d accounts of the last run kept: their rows were seen but not confidently read", carried)
+	}
+
+	if len(previous.Accounts) > 0 {
+		diff := ad.Compare(previous.Accounts, current.Accounts)
+		for _, rename := range diff.Renamed {
+			log.Printf("probable rename: %s -> %s (avatar distance %d)", rename.Previous.Username, rename.Current.Username, rename.Distance)
+		}
+		for _, account := range diff.New {
+			log.Printf("new: %s", account.Username)
+		}
+		for _, account := range diff.Gone {
+			log.Printf("gone: %s", account.Username)
+		}
+	}
+
+	return current.Save(config.AccountSnapshotPath)
+}
+
+// countInferredRows returns the number of rows without a matched button.
+func countInferredRows(rows []screenshotuserextractor.Row) int {
+	count := 0
+	for _, row := range rows {
+		if row.Inferred {
+			count++
+		}
+	}
+
+	return count
+}
+
+// usernamesFromRows returns the usernames read from the list rows, which tell whether the list still
+// scrolls from one frame to the next.
+func usernamesFromRows(rows []screenshotuserextractor.Row) []string {
+	var usernames []string
+	for _, row := range rows {
+		if row.Username != "" && !row.Suggested {
+			usernames = append(usernames, row.Username)
+		}
+	}
+
+	return usernames
+}
+
+// accountsFromRows returns the accounts of the list rows whose username was fully and confidently read,
+// and the rows that were seen but not (e.g. partial, truncated, flagged for review or invalid), with whatever
+// was read of them.
+func accountsFromRows(rows []screenshotuserextractor.Row) ([]accountdiff.Account, []accountdiff.Account) {
+	var accounts, unsure []accountdiff.Account
+	for _, row := range rows {
+		if row.Suggested {
+			continue
+		}
+		account := accountdiff.Account{
+			Username:     row.Username,
+			AvatarHash:   row.AvatarHash,
+			AvatarHashed: row.AvatarHashed,
+		}
+		if row.Username == "" || row.Truncated || row.NeedsReview || row.UsernameStatus == usernamegrammar.StatusInvalid {
+			unsure = append(unsure, account)
+			continue
+		}
+		accounts = append(accounts, account)
+	}
+
+	return accounts, unsure
 }
@@ -0,0 +1,145 @@
+package occlusion
+
+import (
+	"image"
+	"image/color"
+	"sort"
+
+	"github.com/palantir/stacktrace"
+	"github.com/rogeriofbrito/go-insta-scraper-v2/config"
+	"github.com/rogeriofbrito/go-insta-scraper-v2/util"
+	"gocv.io/x/gocv"
+)
+
+// NewOcclusionDetector creates a new OcclusionDetector with the overlay detection parameters of config.
+func NewOcclusionDetector(config *config.Config) *OcclusionDetector {
+	return &OcclusionDetector{
+		config: config,
+	}
+}
+
+// OcclusionDetector finds overlays drawn over the list, such as notification banners, the search bar,
+// the on-screen keyboard and toasts. Overlays are solid panels with a fill that differs from the list
+// background and are wider than anything inside a row (buttons, profile pictures).
+type OcclusionDetector struct {
+	config *config.Config
+}
+
+// Find returns the bounding boxes of the overlays of the screenshot, from top to bottom. Pixels whose gray
+// value differs from the list background (the most frequent gray value of the screenshot) by more than
+// OcclusionInkThreshold are split into connected components, and overlays are the components at least
+// OcclusionMinWidth wide and OcclusionMinHeight high that fill at least OcclusionMinFillRatio of their
+// bounding box. Text lines are not filled and buttons and profile pictures are narrower. Components spanning
+// the full width are overlays only when they touch the top or bottom edge (e.g. the keyboard), since in the
+// middle of the list they are highlighted rows. Content touching an overlay (e.g. a profile picture partly
+// under a banner) is part of its bounding box, so rows next to an overlay may be reported as occluded too.
+func (d *OcclusionDetector) Find(imageMat gocv.Mat) ([]image.Rectangle, error) {
+	bounds := image.Rect(0, 0, imageMat.Cols(), imageMat.Rows())
+	if bounds.Empty() {
+		return nil, nil
+	}
+
+	inkMat, err := d.getInkMask(imageMat)
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "failed to get ink mask")
+	}
+	defer inkMat.Close()
+
+	labelsMat := gocv.NewMat()
+	defer labelsMat.Close()
+	statsMat := gocv.NewMat()
+	defer statsMat.Close()
+	centroidsMat := gocv.NewMat()
+	defer centroidsMat.Close()
+
+	count := gocv.ConnectedComponentsWithStats(inkMat, &labelsMat, &statsMat, &centroidsMat)
+
+	var overlays []image.Rectangle
+	// Label 0 is the background
+	for label := 1; label < count; label++ {
+		left := int(statsMat.GetIntAt(label, int(gocv.CC_STAT_LEFT)))
+		top := int(statsMat.GetIntAt(label, int(gocv.CC_STAT_TOP)))
+		width := int(statsMat.GetIntAt(label, int(gocv.CC_STAT_WIDTH)))
+		height := int(statsMat.GetIntAt(label, int(gocv.CC_STAT_HEIGHT)))
+		area := int(statsMat.GetIntAt(label, int(gocv.CC_STAT_AREA)))
+		component := image.Rect(left, top, left+width, top+height)
+
+		if IsOverlay(component, area, bounds, d.config) {
+			overlays = append(overlays, component)
+		}
+	}
+
+	sort.Slice(overlays, func(i, j int) bool {
+		return overlays[i].Min.Y < overlays[j].Min.Y
+	})
+
+	return overlays, nil
+}
+
+// IsOverlay returns whether a connected component with the given bounding box and pixel count, in a
+// screenshot with the given bounds, is an overlay according to the thresholds of config.
+func IsOverlay(component image.Rectangle, area int, bounds image.Rectangle, config *config.Config) bool {
+	if component.Dx() < config.OcclusionMinWidth || component.Dy() < config.OcclusionMinHeight {
+		return false
+	}
+	if float64(area) < config.OcclusionMinFillRatio*float64(component.Dx()*component.Dy()) {
+		return false
+	}
+
+	fullWidth := component.Min.X <= bounds.Min.X && component.Max.X >= bounds.Max.X
+	touchesTopOrBottom := component.Min.Y <= bounds.Min.Y || component.Max.Y >= bounds.Max.Y
+
+	return !fullWidth || touchesTopOrBottom
+}
+
+// Mask paints the overlays of the image with its background color (the most frequent gray value of the
+// image), so nothing is matched or read inside them.
+func Mask(imageMat *gocv.Mat, overlays []image.Rectangle) error {
+	if len(overlays) == 0 {
+		return nil
+	}
+
+	background, err := util.GetDominantLevel(*imageMat, image.Rect(0, 0, imageMat.Cols(), imageMat.Rows()))
+	if err != nil {
+		return stacktrace.Propagate(err, "failed to get background level")
+	}
+
+	fill := color.RGBA{R: background, G: background, B: background, A: 0}
+	for _, overlay := range overlays {
+		err := gocv.Rectangle(imageMat, overlay, fill, -1)
+		if err != nil {
+			return stacktrace.Propagate(err, "failed to mask overlay %v", overlay)
+		}
+	}
+
+	return nil
+}
+
+// getInkMask returns a binary mask of the image where pixels whose gray value differs from the list
+// background by more than OcclusionInkThreshold are set.
+func (d *OcclusionDetector) getInkMask(imageMat gocv.Mat) (gocv.Mat, error) {
+	bounds := image.Rect(0, 0, imageMat.Cols(), imageMat.Rows())
+	background, err := util.GetDominantLevel(imageMat, bounds)
+	if err != nil {
+		return gocv.Mat{}, stacktrace.Propagate(err, "failed to get background level")
+	}
+
+	grayMat, err := util.GetGrayRegion(imageMat, bounds)
+	if err != nil {
+		return gocv.Mat{}, stacktrace.Propagate(err, "failed to get gray image")
+	}
+	defer grayMat.Close()
+
+	backgroundMat := gocv.NewMatWithSizeFromScalar(gocv.NewScalar(float64(background), 0, 0, 0), grayMat.Rows(), grayMat.Cols(), gocv.MatTypeCV8U)
+	defer backgroundMat.Close()
+
+	inkMat := gocv.NewMat()
+	err = gocv.AbsDiff(grayMat, backgroundMat, &inkMat)
+	if err != nil {
+		inkMat.Close()
+		return gocv.Mat{}, stacktrace.Propagate(err, "failed to subtract background")
+	}
+	gocv.Threshold(inkMat, &inkMat, float32(d.config.OcclusionInkThreshold), 255, gocv.ThresholdBinary)
+
+	return inkMat, nil
+}
@@ -0,0 +1,189 @@
+package occlusion_test
+
+import (
+	"image"
+	"testing"
+
+	"github.com/rogeriofbrito/go-insta-scraper-v2/config"
+	"github.com/rogeriofbrito/go-insta-scraper-v2/occlusion"
+	"gocv.io/x/gocv"
+)
+
+func newConfig() *config.Config {
+	return &config.Config{
+		OcclusionInkThreshold: 8,
+		OcclusionMinWidth:     300,
+		OcclusionMinHeight:    40,
+		OcclusionMinFillRatio: 0.6,
+	}
+}
+
+func TestOcclusionDetector_Find_DiverseCases(t *testing.T) {
+	// Allowed distance between expected and found edges, to absorb anti-aliasing
+	const tolerance = 2
+
+	tests := []struct {
+		name             string
+		imagePath        string
+		flags            gocv.IMReadFlag
+		expectedOverlays []image.Rectangle
+	}{
+		{
+			name:             "plain_list_has_no_overlays",
+			imagePath:        "testdata/plain_list.png",
+			flags:            gocv.IMReadColor,
+			expectedOverlays: nil,
+		},
+		{
+			// The banner box includes the profile picture it partly covers, and the keyboard box the one
+			// right above it. The highlighted row spans the full width in the middle of the list, so it is
+			// not an overlay.
+			name:      "banner_and_keyboard_found_highlighted_row_ignored",
+			imagePath: "testdata/banner_highlight_keyboard.png",
+			flags:     gocv.IMReadColor,
+			expectedOverlays: []image.Rectangle{
+				image.Rect(16, 0, 872, 195),
+				image.Rect(0, 655, 888, 900),
+			},
+		},
+		{
+			name:             "toast",
+			imagePath:        "testdata/toast.png",
+			flags:            gocv.IMReadColor,
+			expectedOverlays: []image.Rectangle{image.Rect(245, 620, 643, 690)},
+		},
+		{
+			name:             "toast_grayscale",
+			imagePath:        "testdata/toast.png",
+			flags:            gocv.IMReadGrayScale,
+			expectedOverlays: []image.Rectangle{image.Rect(245, 620, 643, 690)},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			imageMat := gocv.IMRead(tc.imagePath, tc.flags)
+			if imageMat.Empty() {
+				t.Fatalf("failed to load image: %s", tc.imagePath)
+			}
+			defer imageMat.Close()
+
+			d := occlusion.NewOcclusionDetector(newConfig())
+
+			overlays, err := d.Find(imageMat)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if len(overlays) != len(tc.expectedOverlays) {
+				t.Fatalf("Find() = %v; expected %v", overlays, tc.expectedOverlays)
+			}
+			for i, overlay := range overlays {
+				expected := tc.expectedOverlays[i]
+				if abs(overlay.Min.X-expected.Min.X) > tolerance || abs(overlay.Min.Y-expected.Min.Y) > tolerance ||
+					abs(overlay.Max.X-expected.Max.X) > tolerance || abs(overlay.Max.Y-expected.Max.Y) > tolerance {
+					t.Errorf("Find() = %v; expected %v", overlays, tc.expectedOverlays)
+				}
+			}
+		})
+	}
+}
+
+func TestOcclusionDetector_Mask(t *testing.T) {
+	imagePath := "testdata/toast.png"
+	imageMat := gocv.IMRead(imagePath, gocv.IMReadColor)
+	if imageMat.Empty() {
+		t.Fatalf("failed to load image: %s", imagePath)
+	}
+	defer imageMat.Close()
+
+	d := occlusion.NewOcclusionDetector(newConfig())
+	overlays, err := d.Find(imageMat)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	err = occlusion.Mask(&imageMat, overlays)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	overlays, err = d.Find(imageMat)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(overlays) != 0 {
+		t.Errorf("Find() after Mask() = %v; expected no overlays", overlays)
+	}
+}
+
+func TestIsOverlay_DiverseCases(t *testing.T) {
+	bounds := image.Rect(0, 0, 888, 1920)
+
+	tests := []struct {
+		name      string
+		component image.Rectangle
+		fillRatio float64
+		expected  bool
+	}{
+		{
+			name:      "banner",
+			component: image.Rect(16, 60, 872, 220),
+			fillRatio: 0.95,
+			expected:  true,
+		},
+		{
+			name:      "button_is_too_narrow",
+			component: image.Rect(628, 390, 865, 459),
+			fillRatio: 0.98,
+			expected:  false,
+		},
+		{
+			name:      "text_line_is_too_short",
+			component: image.Rect(173, 390, 573, 423),
+			fillRatio: 0.9,
+			expected:  false,
+		},
+		{
+			name:      "merged_text_lines_are_not_filled",
+			component: image.Rect(173, 390, 573, 455),
+			fillRatio: 0.5,
+			expected:  false,
+		},
+		{
+			name:      "full_width_highlighted_row",
+			component: image.Rect(0, 700, 888, 850),
+			fillRatio: 0.9,
+			expected:  false,
+		},
+		{
+			name:      "keyboard_touching_bottom_edge",
+			component: image.Rect(0, 1200, 888, 1920),
+			fillRatio: 0.9,
+			expected:  true,
+		},
+		{
+			name:      "sticky_header_touching_top_edge",
+			component: image.Rect(0, 0, 888, 120),
+			fillRatio: 0.9,
+			expected:  true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			area := int(tc.fillRatio * float64(tc.component.Dx()*tc.component.Dy()))
+			got := occlusion.IsOverlay(tc.component, area, bounds, newConfig())
+			if got != tc.expected {
+				t.Errorf("IsOverlay(%v, %d) = %v; expected %v", tc.component, area, got, tc.expected)
+			}
+		})
+	}
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
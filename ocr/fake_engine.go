@@ -0,0 +1,64 @@
+package ocr
+
+import (
+	"context"
+	"image"
+	"slices"
+	"sync"
+
+	"github.com/palantir/stacktrace"
+	"gocv.io/x/gocv"
+)
+
+// NewFakeEngine creates an in-memory Engine that returns results in the order they are given, one per
+// Read call, regardless of the image. Calls past the last result read an empty region.
+func NewFakeEngine(results ...Result) *FakeEngine {
+	return &FakeEngine{
+		results: results,
+	}
+}
+
+// FakeEngine is an Engine for tests: it does not look at the image and records the regions it was asked to read.
+// It is safe for concurrent use, in which case results are handed out in the order calls arrive.
+type FakeEngine struct {
+	mu      sync.Mutex
+	results []Result
+	err     error             // Returned by every Read call when set
+	rects   []image.Rectangle // Regions passed to Read, in call order
+}
+
+// FailWith makes every later Read call fail with err.
+func (f *FakeEngine) FailWith(err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.err = err
+}
+
+// Rects returns the regions read so far, in call order.
+func (f *FakeEngine) Rects() []image.Rectangle {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return slices.Clone(f.rects)
+}
+
+func (f *FakeEngine) Read(ctx context.Context, imageMat gocv.Mat, rect image.Rectangle) (Result, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if ctx.Err() != nil {
+		return Result{}, stacktrace.Propagate(ctx.Err(), "failed to read region %v", rect)
+	}
+
+	f.rects = append(f.rects, rect)
+	if f.err != nil {
+		return Result{}, stacktrace.Propagate(f.err, "failed to read region %v", rect)
+	}
+
+	if len(f.rects) > len(f.results) {
+		return Result{}, nil
+	}
+
+	return f.results[len(f.rects)-1], nil
+}
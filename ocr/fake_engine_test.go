@@ -0,0 +1,97 @@
+package ocr_test
+
+import (
+	"context"
+	"errors"
+	"image"
+	"reflect"
+	"testing"
+
+	"github.com/rogeriofbrito/go-insta-scraper-v2/ocr"
+	"gocv.io/x/gocv"
+)
+
+func TestFakeEngine_Read_DiverseCases(t *testing.T) {
+	rects := []image.Rectangle{
+		image.Rect(165, 518, 605, 554),
+		image.Rect(165, 667, 605, 703),
+		image.Rect(165, 817, 605, 853),
+	}
+
+	tests := []struct {
+		name            string
+		results         []ocr.Result
+		err             error
+		expectedResults []ocr.Result
+		expectErr       bool
+	}{
+		{
+			name: "results_returned_in_call_order",
+			results: []ocr.Result{
+				{Text: "matheusgonze1", Confidence: 95},
+				{Text: "stephencurry30", Confidence: 91},
+				{Text: "kvraco", Confidence: 88},
+			},
+			expectedResults: []ocr.Result{
+				{Text: "matheusgonze1", Confidence: 95},
+				{Text: "stephencurry30", Confidence: 91},
+				{Text: "kvraco", Confidence: 88},
+			},
+		},
+		{
+			name: "calls_past_last_result_read_nothing",
+			results: []ocr.Result{
+				{Text: "matheusgonze1", Confidence: 95},
+			},
+			expectedResults: []ocr.Result{
+				{Text: "matheusgonze1", Confidence: 95},
+				{},
+				{},
+			},
+		},
+		{
+			name:      "failing_engine",
+			results:   []ocr.Result{{Text: "matheusgonze1", Confidence: 95}},
+			err:       errors.New("engine unavailable"),
+			expectErr: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			imageMat := gocv.NewMat()
+			defer imageMat.Close()
+
+			engine := ocr.NewFakeEngine(tc.results...)
+			if tc.err != nil {
+				engine.FailWith(tc.err)
+			}
+
+			for i, rect := range rects {
+				result, err := engine.Read(context.Background(), imageMat, rect)
+				if tc.expectErr {
+					if err == nil {
+						t.Fatalf("expected error but got nil")
+					}
+					continue
+				}
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if !reflect.DeepEqual(result, tc.expectedResults[i]) {
+					t.Fatalf("Read() #%d = %+v; expected %+v", i, result, tc.expectedResults[i])
+				}
+			}
+
+			readRects := engine.Rects()
+			if len(readRects) != len(rects) {
+				t.Fatalf("Rects() = %v; expected %v", readRects, rects)
+			}
+			for i := range rects {
+				if readRects[i] != rects[i] {
+					t.Fatalf("Rects() = %v; expected %v", readRects, rects)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,134 @@
+package ocr
+
+import (
+	"context"
+	"image"
+
+	"github.com/palantir/stacktrace"
+	"gocv.io/x/gocv"
+)
+
+// Result is the text read from an image region.
+type Result struct {
+	Text       string  // Recognized text, one line per text line of the region, empty when nothing was read
+	Confidence float64 // Mean confidence of the recognized words, from 0 to 100
+	Words      []Word  // Recognized words, in reading order
+}
+
+// Word is a word of a Result.
+type Word struct {
+	Text       string          // Recognized text
+	Rect       image.Rectangle // Bounding box of the word in the image
+	Confidence float64         // Word confidence, from 0 to 100
+	Chars      []Char          // Characters of the word, empty when the engine does not report them
+}
+
+// Char is a character of a Word.
+type Char struct {
+	Text       string          // Recognized character
+	Rect       image.Rectangle // Bounding box of the character in the image
+	Confidence float64         // Character confidence, from 0 to 100
+}
+
+// MinConfidence returns the lowest confidence among the words of the result, 0 when there are none.
+// Unlike Confidence, a single unsure word is enough to make it low.
+func (r Result) MinConfidence() float64 {
+	if len(r.Words) == 0 {
+		return 0
+	}
+
+	minConfidence := r.Words[0].Confidence
+	for _, word := range r.Words[1:] {
+		minConfidence = min(minConfidence, word.Confidence)
+	}
+
+	return minConfidence
+}
+
+// Engine reads the text inside a region of an image. Implementations are expected to read only the
+// part of rect inside the image, to return an error when nothing of rect is left and to give up as soon
+// as ctx is done.
+type Engine interface {
+	Read(ctx context.Context, imageMat gocv.Mat, rect image.Rectangle) (Result, error)
+}
+
+// BatchEngine is an Engine that can read several regions of an image in a single call, which is cheaper
+// than one Read per region when each call has a fixed cost (e.g. starting a process).
+type BatchEngine interface {
+	Engine
+	ReadBatch(ctx context.Context, imageMat gocv.Mat, rects []image.Rectangle) ([]Result, error)
+}
+
+// ReadEach reads every rect with its own Read call, with up to concurrency calls running at the same time
+// (one at a time when concurrency is below 2), so engine must be safe for concurrent use. Results are returned
+// in the order of rects. Empty rects are not read and get an empty result. When several rects fail, the error
+// of the first one is returned, naming its index in rects. No more calls are started once ctx is done.
+func ReadEach(ctx context.Context, engine Engine, imageMat gocv.Mat, rects []image.Rectangle, concurrency int) ([]Result, error) {
+	results := make([]Result, len(rects))
+	err := forEach(ctx, len(rects), concurrency, func(i int) error {
+		if rects[i].Empty() {
+			return nil
+		}
+
+		result, err := engine.Read(ctx, imageMat, rects[i])
+		if err != nil {
+			return stacktrace.Propagate(err, "failed to read region %d at %v", i, rects[i])
+		}
+		results[i] = result
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+// ReadAll reads every rect with as few calls as possible when engine is a BatchEngine, falling back to
+// ReadEach otherwise. The rects are split into up to concurrency consecutive chunks of at least minBatchSize
+// rects each, so a few rects are never spread over one call per rect, and each chunk is read with one
+// ReadBatch call, all running at the same time. Results are returned in the order of rects. Empty rects are
+// not read and get an empty result. When several chunks fail, the error of the first one is returned,
+// naming the indexes in rects it holds. No more calls are started once ctx is done.
+func ReadAll(ctx context.Context, engine Engine, imageMat gocv.Mat, rects []image.Rectangle, minBatchSize, concurrency int) ([]Result, error) {
+	batchEngine, ok := engine.(BatchEngine)
+	if !ok {
+		return ReadEach(ctx, engine, imageMat, rects, concurrency)
+	}
+
+	var readIndexes []int
+	for i, rect := range rects {
+		if !rect.Empty() {
+			readIndexes = append(readIndexes, i)
+		}
+	}
+
+	chunks := splitChunks(readIndexes, min(concurrency, len(readIndexes)/max(minBatchSize, 1)))
+	results := make([]Result, len(rects))
+	err := forEach(ctx, len(chunks), len(chunks), func(c int) error {
+		chunkRects := make([]image.Rectangle, len(chunks[c]))
+		for j, i := range chunks[c] {
+			chunkRects[j] = rects[i]
+		}
+
+		chunkResults, err := batchEngine.ReadBatch(ctx, imageMat, chunkRects)
+		if err != nil {
+			return stacktrace.Propagate(err, "failed to read regions %v", chunks[c])
+		}
+		if len(chunkResults) != len(chunkRects) {
+			return stacktrace.NewError("failed to read regions %v: engine returned %d results", chunks[c], len(chunkResults))
+		}
+
+		for j, i := range chunks[c] {
+			results[i] = chunkResults[j]
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
@@ -0,0 +1,351 @@
+package ocr_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/palantir/stacktrace"
+	"github.com/rogeriofbrito/go-insta-scraper-v2/ocr"
+	"gocv.io/x/gocv"
+)
+
+// rectEngine reads the top of each rect as its text, so results can be checked against rects whatever the
+// order calls run in. Reading a rect whose top is in failing fails.
+type rectEngine struct {
+	failing []int
+
+	mu         sync.Mutex
+	batchSizes []int // Number of rects of each ReadBatch call, sorted
+	readCalls  int
+}
+
+func (e *rectEngine) Read(ctx context.Context, imageMat gocv.Mat, rect image.Rectangle) (ocr.Result, error) {
+	e.mu.Lock()
+	e.readCalls++
+	e.mu.Unlock()
+
+	if slices.Contains(e.failing, rect.Min.Y) {
+		return ocr.Result{}, errors.New("engine unavailable")
+	}
+
+	return ocr.Result{Text: fmt.Sprint(rect.Min.Y), Confidence: 90}, nil
+}
+
+// batchRectEngine is a rectEngine that also reads in batches. When short is set, its batches return one
+// result less than the rects they were given.
+type batchRectEngine struct {
+	rectEngine
+	short bool
+}
+
+func (e *batchRectEngine) ReadBatch(ctx context.Context, imageMat gocv.Mat, rects []image.Rectangle) ([]ocr.Result, error) {
+	e.mu.Lock()
+	e.batchSizes = append(e.batchSizes, len(rects))
+	slices.Sort(e.batchSizes)
+	e.mu.Unlock()
+
+	var results []ocr.Result
+	for _, rect := range rects {
+		if slices.Contains(e.failing, rect.Min.Y) {
+			return nil, errors.New("engine unavailable")
+		}
+		results = append(results, ocr.Result{Text: fmt.Sprint(rect.Min.Y), Confidence: 90})
+	}
+	if e.short {
+		results = results[1:]
+	}
+
+	return results, nil
+}
+
+// rowRects returns a 36px tall rect for each given top, an empty rect for a negative top.
+func rowRects(tops ...int) []image.Rectangle {
+	rects := make([]image.Rectangle, len(tops))
+	for i, top := range tops {
+		if top >= 0 {
+			rects[i] = image.Rect(165, top, 605, top+36)
+		}
+	}
+
+	return rects
+}
+
+func TestReadEach_DiverseCases(t *testing.T) {
+	tests := []struct {
+		name          string
+		rects         []image.Rectangle
+		concurrency   int
+		failing       []int
+		expectedTexts []string
+		expectedErr   string
+	}{
+		{
+			name:          "sequential",
+			rects:         rowRects(518, 667, 817),
+			concurrency:   0,
+			expectedTexts: []string{"518", "667", "817"},
+		},
+		{
+			name:          "concurrent_results_keep_rect_order",
+			rects:         rowRects(518, 667, 817, 966, 1116, 1265, 1415),
+			concurrency:   3,
+			expectedTexts: []string{"518", "667", "817", "966", "1116", "1265", "1415"},
+		},
+		{
+			name:          "more_workers_than_rects",
+			rects:         rowRects(518, 667),
+			concurrency:   8,
+			expectedTexts: []string{"518", "667"},
+		},
+		{
+			name:          "empty_rects_not_read",
+			rects:         rowRects(518, -1, 817),
+			concurrency:   2,
+			expectedTexts: []string{"518", "", "817"},
+		},
+		{
+			name:        "sequential_error_names_failing_region",
+			rects:       rowRects(518, 667, 817),
+			concurrency: 1,
+			failing:     []int{667},
+			expectedErr: "failed to read region 1 at",
+		},
+		{
+			name:        "concurrent_error_names_first_failing_region",
+			rects:       rowRects(518, 667, 817, 966, 1116),
+			concurrency: 4,
+			failing:     []int{1116, 817},
+			expectedErr: "failed to read region 2 at",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			imageMat := gocv.NewMat()
+			defer imageMat.Close()
+
+			engine := &rectEngine{failing: tc.failing}
+			results, err := ocr.ReadEach(context.Background(), engine, imageMat, tc.rects, tc.concurrency)
+			if tc.expectedErr != "" {
+				if err == nil {
+					t.Fatalf("expected error but got nil")
+				}
+				if !strings.Contains(err.Error(), tc.expectedErr) {
+					t.Fatalf("ReadEach() error = %q; expected it to contain %q", err.Error(), tc.expectedErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(results) != len(tc.expectedTexts) {
+				t.Fatalf("ReadEach() = %+v; expected texts %v", results, tc.expectedTexts)
+			}
+			for i, result := range results {
+				if result.Text != tc.expectedTexts[i] {
+					t.Errorf("ReadEach()[%d] = %q; expected %q", i, result.Text, tc.expectedTexts[i])
+				}
+			}
+		})
+	}
+}
+
+func TestReadAll_DiverseCases(t *testing.T) {
+	tests := []struct {
+		name               string
+		engine             ocr.Engine
+		rects              []image.Rectangle
+		minBatchSize       int
+		concurrency        int
+		expectedTexts      []string
+		expectedBatchSizes []int
+		expectedErr        string
+	}{
+		{
+			name:          "engine_without_batch_read_once_per_rect",
+			engine:        &rectEngine{},
+			rects:         rowRects(518, 667, 817),
+			concurrency:   2,
+			expectedTexts: []string{"518", "667", "817"},
+		},
+		{
+			name:               "single_batch",
+			engine:             &batchRectEngine{},
+			rects:              rowRects(518, 667, 817),
+			concurrency:        0,
+			expectedTexts:      []string{"518", "667", "817"},
+			expectedBatchSizes: []int{3},
+		},
+		{
+			name:               "batches_split_by_concurrency",
+			engine:             &batchRectEngine{},
+			rects:              rowRects(518, 667, 817, 966, 1116),
+			concurrency:        2,
+			expectedTexts:      []string{"518", "667", "817", "966", "1116"},
+			expectedBatchSizes: []int{2, 3},
+		},
+		{
+			name:               "no_more_batches_than_rects",
+			engine:             &batchRectEngine{},
+			rects:              rowRects(518, 667),
+			concurrency:        8,
+			expectedTexts:      []string{"518", "667"},
+			expectedBatchSizes: []int{1, 1},
+		},
+		{
+			name:               "empty_rects_not_read",
+			engine:             &batchRectEngine{},
+			rects:              rowRects(-1, 667, -1, 966),
+			concurrency:        1,
+			expectedTexts:      []string{"", "667", "", "966"},
+			expectedBatchSizes: []int{2},
+		},
+		{
+			name:               "min_batch_size_keeps_a_single_batch",
+			engine:             &batchRectEngine{},
+			rects:              rowRects(518, 667, 817, 966, 1116),
+			minBatchSize:       16,
+			concurrency:        8,
+			expectedTexts:      []string{"518", "667", "817", "966", "1116"},
+			expectedBatchSizes: []int{5},
+		},
+		{
+			name:               "min_batch_size_limits_batches",
+			engine:             &batchRectEngine{},
+			rects:              rowRects(518, 667, 817, 966, 1116),
+			minBatchSize:       2,
+			concurrency:        8,
+			expectedTexts:      []string{"518", "667", "817", "966", "1116"},
+			expectedBatchSizes: []int{2, 3},
+		},
+		{
+			name:          "no_rects",
+			engine:        &batchRectEngine{},
+			rects:         nil,
+			concurrency:   4,
+			expectedTexts: nil,
+		},
+		{
+			name:        "batch_returning_fewer_results",
+			engine:      &batchRectEngine{short: true},
+			rects:       rowRects(518, 667, 817),
+			concurrency: 1,
+			expectedErr: "engine returned 2 results",
+		},
+		{
+			name:        "error_names_regions_of_failing_batch",
+			engine:      &batchRectEngine{rectEngine: rectEngine{failing: []int{966}}},
+			rects:       rowRects(518, 667, 817, 966),
+			concurrency: 2,
+			expectedErr: "failed to read regions [2 3]",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			imageMat := gocv.NewMat()
+			defer imageMat.Close()
+
+			results, err := ocr.ReadAll(context.Background(), tc.engine, imageMat, tc.rects, tc.minBatchSize, tc.concurrency)
+			if tc.expectedErr != "" {
+				if err == nil {
+					t.Fatalf("expected error but got nil")
+				}
+				if !strings.Contains(err.Error(), tc.expectedErr) {
+					t.Fatalf("ReadAll() error = %q; expected it to contain %q", err.Error(), tc.expectedErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(results) != len(tc.expectedTexts) {
+				t.Fatalf("ReadAll() = %+v; expected texts %v", results, tc.expectedTexts)
+			}
+			for i, result := range results {
+				if result.Text != tc.expectedTexts[i] {
+					t.Errorf("ReadAll()[%d] = %q; expected %q", i, result.Text, tc.expectedTexts[i])
+				}
+			}
+			if engine, ok := tc.engine.(*batchRectEngine); ok {
+				if engine.readCalls != 0 {
+					t.Errorf("Read called %d times; expected only ReadBatch calls", engine.readCalls)
+				}
+				if !slices.Equal(engine.batchSizes, tc.expectedBatchSizes) {
+					t.Errorf("ReadBatch sizes = %v; expected %v", engine.batchSizes, tc.expectedBatchSizes)
+				}
+			}
+		})
+	}
+}
+
+func TestReadEach_CanceledContext(t *testing.T) {
+	for _, concurrency := range []int{1, 4} {
+		t.Run(fmt.Sprintf("concurrency_%d", concurrency), func(t *testing.T) {
+			imageMat := gocv.NewMat()
+			defer imageMat.Close()
+
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+
+			engine := &rectEngine{}
+			_, err := ocr.ReadEach(ctx, engine, imageMat, rowRects(518, 667, 817), concurrency)
+			if err == nil {
+				t.Fatalf("expected error but got nil")
+			}
+			if !errors.Is(stacktrace.RootCause(err), context.Canceled) {
+				t.Fatalf("ReadEach() error = %v; expected it to be caused by context.Canceled", err)
+			}
+			if engine.readCalls != 0 {
+				t.Errorf("Read called %d times; expected no call once the context is done", engine.readCalls)
+			}
+		})
+	}
+}
+
+func TestResult_MinConfidence_DiverseCases(t *testing.T) {
+	tests := []struct {
+		name     string
+		result   ocr.Result
+		expected float64
+	}{
+		{
+			name:     "no_words",
+			result:   ocr.Result{},
+			expected: 0,
+		},
+		{
+			name: "single_word",
+			result: ocr.Result{
+				Text:       "kvraco",
+				Confidence: 87,
+				Words:      []ocr.Word{{Text: "kvraco", Confidence: 87}},
+			},
+			expected: 87,
+		},
+		{
+			name: "lowest_word_wins_over_mean",
+			result: ocr.Result{
+				Text:       "Enviar mensagem",
+				Confidence: 70,
+				Words:      []ocr.Word{{Text: "Enviar", Confidence: 95}, {Text: "mensagem", Confidence: 45}},
+			},
+			expected: 45,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			minConfidence := tc.result.MinConfidence()
+			if minConfidence != tc.expected {
+				t.Fatalf("MinConfidence() = %v; expected %v", minConfidence, tc.expected)
+			}
+		})
+	}
+}
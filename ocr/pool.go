@@ -0,0 +1,96 @@
+package ocr
+
+import (
+	"context"
+	"sync"
+
+	"github.com/palantir/stacktrace"
+)
+
+// forEach calls read for every index from 0 to count-1 with up to concurrency calls running at the same
+// time, one at a time when concurrency is below 2. Returns the error of the lowest failing index. No more
+// calls are started once ctx is done, in which case the context error is returned if no call failed.
+func forEach(ctx context.Context, count int, concurrency int, read func(i int) error) error {
+	if concurrency < 2 {
+		for i := range count {
+			if ctx.Err() != nil {
+				return stacktrace.Propagate(ctx.Err(), "stopped before reading region %d", i)
+			}
+
+			err := read(i)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	errs := make([]error, count)
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for range min(concurrency, count) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				if ctx.Err() != nil {
+					errs[i] = stacktrace.Propagate(ctx.Err(), "stopped before reading region %d", i)
+					continue
+				}
+				errs[i] = read(i)
+			}
+		}()
+	}
+
+	stopped := -1
+dispatch:
+	for i := range count {
+		select {
+		case indexes <- i:
+		case <-ctx.Done():
+			stopped = i
+			break dispatch
+		}
+	}
+	close(indexes)
+	wg.Wait()
+
+	err := firstError(errs)
+	if err == nil && stopped != -1 {
+		return stacktrace.Propagate(ctx.Err(), "stopped before reading region %d", stopped)
+	}
+
+	return err
+}
+
+func firstError(errs []error) error {
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// splitChunks splits indexes into up to count consecutive chunks of sizes differing by at most one,
+// the first chunks being the larger ones. A count below 2 gives a single chunk. No chunk is empty.
+func splitChunks(indexes []int, count int) [][]int {
+	if len(indexes) == 0 {
+		return nil
+	}
+	count = min(max(count, 1), len(indexes))
+
+	chunks := make([][]int, 0, count)
+	start := 0
+	for c := range count {
+		size := len(indexes) / count
+		if c < len(indexes)%count {
+			size++
+		}
+		chunks = append(chunks, indexes[start:start+size])
+		start += size
+	}
+
+	return chunks
+}
@@ -0,0 +1,173 @@
+package preprocess
+
+import (
+	"image"
+	"image/color"
+	"math"
+
+	"github.com/palantir/stacktrace"
+	"github.com/rogeriofbrito/go-insta-scraper-v2/config"
+	"github.com/rogeriofbrito/go-insta-scraper-v2/util"
+	"gocv.io/x/gocv"
+)
+
+// darkBackgroundLevel is the gray level below which the background of a crop is dark, so its text is light.
+const darkBackgroundLevel = 128
+
+// sharpenSigma is the standard deviation, in pixels of the upscaled crop, of the blur the unsharp mask removes.
+const sharpenSigma = 1.0
+
+// NewPreprocessor creates a new Preprocessor running the OcrPreprocess steps enabled in config.
+func NewPreprocessor(config *config.Config) *Preprocessor {
+	return &Preprocessor{
+		config: config,
+	}
+}
+
+// Preprocessor prepares username crops for OCR. Each step is enabled separately in config, so its effect
+// on accuracy can be measured on its own.
+type Preprocessor struct {
+	config *config.Config
+}
+
+// Enabled reports whether any preprocessing step is enabled.
+func (p *Preprocessor) Enabled() bool {
+	return p.upscales() || p.config.OcrPreprocessInvertDark || p.config.OcrPreprocessSharpen ||
+		p.config.OcrPreprocessBinarization != config.BinarizationNone || p.pads()
+}
+
+// Apply returns the rect region of imageMat with the enabled steps applied, in this order: conversion to
+// grayscale, upscaling, inversion of a dark background, sharpening, binarization and padding. The region
+// is copied as is when no step is enabled. Boxes found in the returned mat are mapped back to the region
+// with MapRect. The returned mat must be closed by the caller.
+func (p *Preprocessor) Apply(imageMat gocv.Mat, rect image.Rectangle) (gocv.Mat, error) {
+	if !p.Enabled() {
+		regionMat := imageMat.Region(rect)
+		defer regionMat.Close()
+
+		return regionMat.Clone(), nil
+	}
+
+	mat, err := util.GetGrayRegion(imageMat, rect)
+	if err != nil {
+		return gocv.Mat{}, stacktrace.Propagate(err, "failed to get gray region %v", rect)
+	}
+
+	steps := []struct {
+		name    string
+		enabled bool
+		apply   func(src gocv.Mat, dst *gocv.Mat) error
+	}{
+		{"upscale", p.upscales(), p.upscale},
+		{"invert dark background", p.config.OcrPreprocessInvertDark, p.invertDark},
+		{"sharpen", p.config.OcrPreprocessSharpen, p.sharpen},
+		{"binarize", p.config.OcrPreprocessBinarization != config.BinarizationNone, p.binarize},
+		{"pad", p.pads(), p.pad},
+	}
+	for _, step := range steps {
+		if !step.enabled {
+			continue
+		}
+
+		stepMat := gocv.NewMat()
+		err = step.apply(mat, &stepMat)
+		mat.Close()
+		if err != nil {
+			stepMat.Close()
+			return gocv.Mat{}, stacktrace.Propagate(err, "failed to %s region %v", step.name, rect)
+		}
+		mat = stepMat
+	}
+
+	return mat, nil
+}
+
+// MapRect maps rect, in the coordinates of a mat returned by Apply, back to the coordinates of the region
+// it was made from, undoing the padding and the upscaling. The mapped rect covers every region pixel rect
+// touches.
+func (p *Preprocessor) MapRect(rect image.Rectangle) image.Rectangle {
+	if p.pads() {
+		rect = rect.Sub(image.Pt(p.config.OcrPreprocessPadding, p.config.OcrPreprocessPadding))
+	}
+	if p.upscales() {
+		factor := p.config.OcrPreprocessUpscaleFactor
+		rect = image.Rect(
+			int(math.Floor(float64(rect.Min.X)/factor)),
+			int(math.Floor(float64(rect.Min.Y)/factor)),
+			int(math.Ceil(float64(rect.Max.X)/factor)),
+			int(math.Ceil(float64(rect.Max.Y)/factor)),
+		)
+	}
+
+	return rect
+}
+
+func (p *Preprocessor) upscales() bool {
+	return p.config.OcrPreprocessUpscale && p.config.OcrPreprocessUpscaleFactor > 0 && p.config.OcrPreprocessUpscaleFactor != 1
+}
+
+func (p *Preprocessor) pads() bool {
+	return p.config.OcrPreprocessPad && p.config.OcrPreprocessPadding > 0
+}
+
+// upscale enlarges src by OcrPreprocessUpscaleFactor with bicubic interpolation, which keeps glyph edges
+// smoother than linear interpolation.
+func (p *Preprocessor) upscale(src gocv.Mat, dst *gocv.Mat) error {
+	factor := p.config.OcrPreprocessUpscaleFactor
+	return gocv.Resize(src, dst, image.Point{}, factor, factor, gocv.InterpolationCubic)
+}
+
+// invertDark inverts src when its dominant level, its background, is dark, so the text becomes dark on a
+// light background as Tesseract expects. Light background crops are copied as is.
+func (p *Preprocessor) invertDark(src gocv.Mat, dst *gocv.Mat) error {
+	background, err := util.GetDominantLevel(src, image.Rect(0, 0, src.Cols(), src.Rows()))
+	if err != nil {
+		return stacktrace.Propagate(err, "failed to get background level")
+	}
+	if background >= darkBackgroundLevel {
+		return src.CopyTo(dst)
+	}
+
+	return gocv.BitwiseNot(src, dst)
+}
+
+// sharpen applies an unsharp mask to src: it adds OcrPreprocessSharpenAmount times the difference between
+// src and its blurred copy, which raises the contrast of thin glyph strokes.
+func (p *Preprocessor) sharpen(src gocv.Mat, dst *gocv.Mat) error {
+	blurredMat := gocv.NewMat()
+	defer blurredMat.Close()
+
+	err := gocv.GaussianBlur(src, &blurredMat, image.Point{}, sharpenSigma, sharpenSigma, gocv.BorderReplicate)
+	if err != nil {
+		return stacktrace.Propagate(err, "failed to blur region")
+	}
+
+	amount := p.config.OcrPreprocessSharpenAmount
+	return gocv.AddWeighted(src, 1+amount, blurredMat, -amount, 0, dst)
+}
+
+// binarize turns src into black and white with the OcrPreprocessBinarization method.
+func (p *Preprocessor) binarize(src gocv.Mat, dst *gocv.Mat) error {
+	switch p.config.OcrPreprocessBinarization {
+	case config.BinarizationOtsu:
+		gocv.Threshold(src, dst, 0, 255, gocv.ThresholdBinary|gocv.ThresholdOtsu)
+		return nil
+	case config.BinarizationAdaptive:
+		return gocv.AdaptiveThreshold(src, dst, 255, gocv.AdaptiveThresholdGaussian, gocv.ThresholdBinary,
+			p.config.OcrPreprocessAdaptiveBlockSize, p.config.OcrPreprocessAdaptiveC)
+	default:
+		return stacktrace.NewError("unknown binarization %d", p.config.OcrPreprocessBinarization)
+	}
+}
+
+// pad surrounds src with OcrPreprocessPadding pixels of its dominant level, its background.
+func (p *Preprocessor) pad(src gocv.Mat, dst *gocv.Mat) error {
+	background, err := util.GetDominantLevel(src, image.Rect(0, 0, src.Cols(), src.Rows()))
+	if err != nil {
+		return stacktrace.Propagate(err, "failed to get background level")
+	}
+
+	padding := p.config.OcrPreprocessPadding
+	value := color.RGBA{R: background, G: background, B: background, A: 255}
+	return gocv.CopyMakeBorder(src, dst, padding, padding, padding, padding, gocv.BorderConstant, value)
+}
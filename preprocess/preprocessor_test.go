@@ -0,0 +1,195 @@
+package preprocess_test
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/rogeriofbrito/go-insta-scraper-v2/config"
+	"github.com/rogeriofbrito/go-insta-scraper-v2/preprocess"
+	"gocv.io/x/gocv"
+)
+
+// newTextMat returns a BGR image of the given background with a filled bar of the given ink standing for
+// a glyph, inside rect.
+func newTextMat(size image.Point, background, ink uint8, rect image.Rectangle) gocv.Mat {
+	mat := gocv.NewMatWithSizeFromScalar(gocv.NewScalar(float64(background), float64(background), float64(background), 0), size.Y, size.X, gocv.MatTypeCV8UC3)
+	gocv.Rectangle(&mat, rect, color.RGBA{R: ink, G: ink, B: ink, A: 255}, -1)
+	return mat
+}
+
+func TestPreprocessor_Apply_DiverseCases(t *testing.T) {
+	// The glyph is at (10,5)-(20,15) of the region
+	regionRect := image.Rect(5, 5, 45, 25)
+	glyphRect := image.Rect(15, 10, 25, 20)
+
+	tests := []struct {
+		name         string
+		config       *config.Config
+		background   uint8
+		ink          uint8
+		expectedSize image.Point
+		inkPoint     image.Point // Center of the glyph in the output
+		// Expected gray levels of the background, at the output corner, and of the glyph
+		expectedBackground uint8
+		expectedInk        uint8
+	}{
+		{
+			name:               "no_step_copies_the_region",
+			config:             &config.Config{},
+			background:         250,
+			ink:                20,
+			expectedSize:       image.Pt(40, 20),
+			inkPoint:           image.Pt(15, 10),
+			expectedBackground: 250,
+			expectedInk:        20,
+		},
+		{
+			name:               "dark_background_is_inverted",
+			config:             &config.Config{OcrPreprocessInvertDark: true},
+			background:         30,
+			ink:                220,
+			expectedSize:       image.Pt(40, 20),
+			inkPoint:           image.Pt(15, 10),
+			expectedBackground: 225,
+			expectedInk:        35,
+		},
+		{
+			name:               "light_background_is_not_inverted",
+			config:             &config.Config{OcrPreprocessInvertDark: true},
+			background:         250,
+			ink:                20,
+			expectedSize:       image.Pt(40, 20),
+			inkPoint:           image.Pt(15, 10),
+			expectedBackground: 250,
+			expectedInk:        20,
+		},
+		{
+			name: "otsu_binarization_after_inversion",
+			config: &config.Config{
+				OcrPreprocessInvertDark:   true,
+				OcrPreprocessBinarization: config.BinarizationOtsu,
+			},
+			background:         30,
+			ink:                220,
+			expectedSize:       image.Pt(40, 20),
+			inkPoint:           image.Pt(15, 10),
+			expectedBackground: 255,
+			expectedInk:        0,
+		},
+		{
+			name: "upscale_and_pad_grow_the_region",
+			config: &config.Config{
+				OcrPreprocessUpscale:       true,
+				OcrPreprocessUpscaleFactor: 2,
+				OcrPreprocessPad:           true,
+				OcrPreprocessPadding:       10,
+			},
+			background:         250,
+			ink:                20,
+			expectedSize:       image.Pt(100, 60),
+			inkPoint:           image.Pt(40, 30),
+			expectedBackground: 250,
+			expectedInk:        20,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			imageMat := newTextMat(image.Pt(60, 30), tt.background, tt.ink, glyphRect)
+			defer imageMat.Close()
+
+			p := preprocess.NewPreprocessor(tt.config)
+			resultMat, err := p.Apply(imageMat, regionRect)
+			if err != nil {
+				t.Fatalf("Apply() unexpected error: %v", err)
+			}
+			defer resultMat.Close()
+
+			size := image.Pt(resultMat.Cols(), resultMat.Rows())
+			if size != tt.expectedSize {
+				t.Fatalf("Apply() size = %v, expected %v", size, tt.expectedSize)
+			}
+
+			grayMat := resultMat
+			if resultMat.Channels() > 1 {
+				grayMat = gocv.NewMat()
+				defer grayMat.Close()
+				gocv.CvtColor(resultMat, &grayMat, gocv.ColorBGRToGray)
+			}
+
+			if level := grayMat.GetUCharAt(tt.inkPoint.Y, tt.inkPoint.X); level != tt.expectedInk {
+				t.Errorf("Apply() glyph level = %d, expected %d", level, tt.expectedInk)
+			}
+			if level := grayMat.GetUCharAt(0, 0); level != tt.expectedBackground {
+				t.Errorf("Apply() background level = %d, expected %d", level, tt.expectedBackground)
+			}
+		})
+	}
+}
+
+func TestPreprocessor_MapRect_DiverseCases(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   *config.Config
+		rect     image.Rectangle
+		expected image.Rectangle
+	}{
+		{
+			name:     "no_step_keeps_the_rect",
+			config:   &config.Config{},
+			rect:     image.Rect(3, 4, 10, 12),
+			expected: image.Rect(3, 4, 10, 12),
+		},
+		{
+			name:     "padding_is_removed",
+			config:   &config.Config{OcrPreprocessPad: true, OcrPreprocessPadding: 10},
+			rect:     image.Rect(13, 14, 20, 22),
+			expected: image.Rect(3, 4, 10, 12),
+		},
+		{
+			name:     "disabled_steps_are_ignored",
+			config:   &config.Config{OcrPreprocessPadding: 10, OcrPreprocessUpscaleFactor: 2},
+			rect:     image.Rect(3, 4, 10, 12),
+			expected: image.Rect(3, 4, 10, 12),
+		},
+		{
+			name:     "upscaling_is_undone",
+			config:   &config.Config{OcrPreprocessUpscale: true, OcrPreprocessUpscaleFactor: 2},
+			rect:     image.Rect(6, 8, 20, 24),
+			expected: image.Rect(3, 4, 10, 12),
+		},
+		{
+			name:     "partial_pixels_are_covered",
+			config:   &config.Config{OcrPreprocessUpscale: true, OcrPreprocessUpscaleFactor: 3},
+			rect:     image.Rect(7, 8, 20, 25),
+			expected: image.Rect(2, 2, 7, 9),
+		},
+		{
+			name: "padding_is_removed_before_upscaling_is_undone",
+			config: &config.Config{
+				OcrPreprocessUpscale:       true,
+				OcrPreprocessUpscaleFactor: 2,
+				OcrPreprocessPad:           true,
+				OcrPreprocessPadding:       10,
+			},
+			rect:     image.Rect(16, 18, 30, 34),
+			expected: image.Rect(3, 4, 10, 12),
+		},
+		{
+			name:     "rect_inside_the_padding_maps_outside_the_region",
+			config:   &config.Config{OcrPreprocessPad: true, OcrPreprocessPadding: 10},
+			rect:     image.Rect(5, 5, 12, 12),
+			expected: image.Rect(-5, -5, 2, 2),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := preprocess.NewPreprocessor(tt.config).MapRect(tt.rect)
+			if got != tt.expected {
+				t.Errorf("MapRect(%v) = %v, expected %v", tt.rect, got, tt.expected)
+			}
+		})
+	}
+}
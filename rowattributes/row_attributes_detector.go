@@ -0,0 +1,115 @@
+package rowattributes
+
+import (
+	"image"
+	"image/color"
+
+	"github.com/palantir/stacktrace"
+	"github.com/rogeriofbrito/go-insta-scraper-v2/config"
+	"github.com/rogeriofbrito/go-insta-scraper-v2/util"
+	"gocv.io/x/gocv"
+)
+
+const (
+	badgeMinHue   = 90  // Minimum hue of the verified badge blue, in OpenCV units (0-180)
+	badgeMaxHue   = 130 // Maximum hue of the verified badge blue, in OpenCV units (0-180)
+	badgeMinValue = 100 // Minimum brightness of the verified badge blue
+)
+
+// NewRowAttributesDetector creates a new RowAttributesDetector with the color thresholds of config.
+func NewRowAttributesDetector(config *config.Config) *RowAttributesDetector {
+	return &RowAttributesDetector{
+		config: config,
+	}
+}
+
+// RowAttributesDetector finds account attributes shown in user rows through color analysis.
+// It needs color (BGR) screenshots.
+type RowAttributesDetector struct {
+	config *config.Config
+}
+
+// HasVerifiedBadge returns whether the searchRect region of the screenshot, next to the username,
+// has at least BadgeMinPixels pixels of the verified badge blue.
+func (d *RowAttributesDetector) HasVerifiedBadge(imageMat gocv.Mat, searchRect image.Rectangle) (bool, error) {
+	if imageMat.Channels() != 3 {
+		return false, stacktrace.NewError("verified badge detection needs a color image, got %d channels", imageMat.Channels())
+	}
+
+	searchRect = searchRect.Intersect(image.Rect(0, 0, imageMat.Cols(), imageMat.Rows()))
+	if searchRect.Empty() {
+		return false, nil
+	}
+
+	region := imageMat.Region(searchRect)
+	defer region.Close()
+
+	hsvMat := gocv.NewMat()
+	defer hsvMat.Close()
+	err := gocv.CvtColor(region, &hsvMat, gocv.ColorBGRToHSV)
+	if err != nil {
+		return false, stacktrace.Propagate(err, "failed to convert region %v to HSV", searchRect)
+	}
+
+	blueMat := gocv.NewMat()
+	defer blueMat.Close()
+	err = gocv.InRangeWithScalar(hsvMat,
+		gocv.NewScalar(badgeMinHue, float64(d.config.BadgeMinSaturation), badgeMinValue, 0),
+		gocv.NewScalar(badgeMaxHue, 255, 255, 0),
+		&blueMat,
+	)
+	if err != nil {
+		return false, stacktrace.Propagate(err, "failed to find badge blue pixels")
+	}
+
+	return gocv.CountNonZero(blueMat) >= d.config.BadgeMinPixels, nil
+}
+
+// HasStoryRing returns whether the profile picture at avatarRect is surrounded by the colored ring of an
+// active story: at least StoryRingMinRatio of the outer StoryRingWidth pixels of the circle are saturated.
+// Rings of stories already seen are gray, so they do not count.
+func (d *RowAttributesDetector) HasStoryRing(imageMat gocv.Mat, avatarRect image.Rectangle) (bool, error) {
+	if imageMat.Channels() != 3 {
+		return false, stacktrace.NewError("story ring detection needs a color image, got %d channels", imageMat.Channels())
+	}
+
+	clippedRect := avatarRect.Intersect(image.Rect(0, 0, imageMat.Cols(), imageMat.Rows()))
+	if clippedRect.Empty() {
+		return false, nil
+	}
+
+	saturationMat, err := util.GetSaturationRegion(imageMat, clippedRect)
+	if err != nil {
+		return false, stacktrace.Propagate(err, "failed to get saturation region")
+	}
+	defer saturationMat.Close()
+	gocv.Threshold(saturationMat, &saturationMat, float32(d.config.StoryRingMinSaturation), 255, gocv.ThresholdBinary)
+
+	// The annulus is drawn in clipped rect coordinates, so parts of it outside the screenshot are left out
+	center := avatarRect.Min.Add(avatarRect.Max).Div(2).Sub(clippedRect.Min)
+	radius := min(avatarRect.Dx(), avatarRect.Dy()) / 2
+	ringMat := gocv.Zeros(clippedRect.Dy(), clippedRect.Dx(), gocv.MatTypeCV8U)
+	defer ringMat.Close()
+	err = gocv.Circle(&ringMat, center, radius, color.RGBA{255, 255, 255, 0}, -1)
+	if err != nil {
+		return false, stacktrace.Propagate(err, "failed to draw ring outer circle")
+	}
+	err = gocv.Circle(&ringMat, center, max(radius-d.config.StoryRingWidth, 0), color.RGBA{0, 0, 0, 0}, -1)
+	if err != nil {
+		return false, stacktrace.Propagate(err, "failed to draw ring inner circle")
+	}
+
+	ringPixels := gocv.CountNonZero(ringMat)
+	if ringPixels == 0 {
+		return false, nil
+	}
+
+	saturatedRingMat := gocv.NewMat()
+	defer saturatedRingMat.Close()
+	err = gocv.BitwiseAnd(saturationMat, ringMat, &saturatedRingMat)
+	if err != nil {
+		return false, stacktrace.Propagate(err, "failed to mask ring pixels")
+	}
+
+	return float64(gocv.CountNonZero(saturatedRingMat)) >= d.config.StoryRingMinRatio*float64(ringPixels), nil
+}
@@ -0,0 +1,89 @@
+package rowattributes_test
+
+import (
+	"image"
+	"testing"
+
+	"github.com/rogeriofbrito/go-insta-scraper-v2/config"
+	"github.com/rogeriofbrito/go-insta-scraper-v2/rowattributes"
+	"gocv.io/x/gocv"
+)
+
+func TestRowAttributesDetector_DiverseCases(t *testing.T) {
+	tests := []struct {
+		name              string
+		imagePath         string
+		badgeSearchRect   image.Rectangle
+		avatarRect        image.Rectangle
+		expectedVerified  bool
+		expectedStoryRing bool
+	}{
+		{
+			name:              "verified_with_story",
+			imagePath:         "testdata/badge_ring_row.png",
+			badgeSearchRect:   image.Rect(392, 41, 442, 70),
+			avatarRect:        image.Rect(25, 8, 157, 140),
+			expectedVerified:  true,
+			expectedStoryRing: true,
+		},
+		{
+			name:              "story_only",
+			imagePath:         "testdata/ring_row.png",
+			badgeSearchRect:   image.Rect(471, 42, 521, 70),
+			avatarRect:        image.Rect(25, 8, 157, 140),
+			expectedVerified:  false,
+			expectedStoryRing: true,
+		},
+		{
+			name:              "verified_only",
+			imagePath:         "testdata/badge_row.png",
+			badgeSearchRect:   image.Rect(361, 43, 411, 71),
+			avatarRect:        image.Rect(25, 8, 157, 140),
+			expectedVerified:  true,
+			expectedStoryRing: false,
+		},
+		{
+			name:              "plain_account",
+			imagePath:         "testdata/plain_row.png",
+			badgeSearchRect:   image.Rect(347, 44, 397, 66),
+			avatarRect:        image.Rect(25, 8, 157, 140),
+			expectedVerified:  false,
+			expectedStoryRing: false,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			imageMat := gocv.IMRead(tc.imagePath, gocv.IMReadColor)
+			if imageMat.Empty() {
+				t.Fatalf("failed to load image: %s", tc.imagePath)
+			}
+			defer imageMat.Close()
+
+			cfg := config.Config{
+				BadgeMinSaturation:     100,
+				BadgeMinPixels:         100,
+				StoryRingWidth:         8,
+				StoryRingMinSaturation: 100,
+				StoryRingMinRatio:      0.5,
+			}
+			d := rowattributes.NewRowAttributesDetector(&cfg)
+
+			verified, err := d.HasVerifiedBadge(imageMat, tc.badgeSearchRect)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if verified != tc.expectedVerified {
+				t.Errorf("HasVerifiedBadge(%v) = %v; expected %v", tc.badgeSearchRect, verified, tc.expectedVerified)
+			}
+
+			storyRing, err := d.HasStoryRing(imageMat, tc.avatarRect)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if storyRing != tc.expectedStoryRing {
+				t.Errorf("HasStoryRing(%v) = %v; expected %v", tc.avatarRect, storyRing, tc.expectedStoryRing)
+			}
+		})
+	}
+}
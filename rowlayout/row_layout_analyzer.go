@@ -0,0 +1,106 @@
+package rowlayout
+
+import (
+	"image"
+
+	"github.com/palantir/stacktrace"
+	"github.com/rogeriofbrito/go-insta-scraper-v2/config"
+	"github.com/rogeriofbrito/go-insta-scraper-v2/util"
+	"gocv.io/x/gocv"
+)
+
+// minInkPerLine is the minimum number of ink pixels for a pixel row to be part of a text line.
+const minInkPerLine = 1
+
+// Layout holds the bounding boxes of the text lines of a user row, in screenshot coordinates.
+type Layout struct {
+	Username    image.Rectangle   // First line, empty if the row has no text
+	DisplayName image.Rectangle   // Second line, empty if the row has a single line
+	Extra       []image.Rectangle // Lines below the display name (e.g. "Followed by…")
+}
+
+// Lines returns the bounding boxes of every line of the layout, from top to bottom.
+func (l Layout) Lines() []image.Rectangle {
+	var lines []image.Rectangle
+	if !l.Username.Empty() {
+		lines = append(lines, l.Username)
+	}
+	if !l.DisplayName.Empty() {
+		lines = append(lines, l.DisplayName)
+	}
+
+	return append(lines, l.Extra...)
+}
+
+// NewRowLayoutAnalyzer creates a new RowLayoutAnalyzer with the line detection parameters of config.
+func NewRowLayoutAnalyzer(config *config.Config) *RowLayoutAnalyzer {
+	return &RowLayoutAnalyzer{
+		config: config,
+	}
+}
+
+// RowLayoutAnalyzer finds the text lines of user rows using projection profiles: pixel rows with
+// ink (pixels that differ from the background) form lines, and the columns with ink inside each line
+// give its horizontal bounds. This works regardless of the row background color, of badges next to
+// the username and of the number of lines.
+type RowLayoutAnalyzer struct {
+	config *config.Config
+}
+
+// Analyze returns the layout of the text lines inside textRect of the screenshot, which should cover
+// the row between the profile picture and the button.
+func (a *RowLayoutAnalyzer) Analyze(screenshotMat gocv.Mat, textRect image.Rectangle) (Layout, error) {
+	lines, err := a.getLines(screenshotMat, textRect)
+	if err != nil {
+		return Layout{}, stacktrace.Propagate(err, "failed to get text lines of %v", textRect)
+	}
+
+	var layout Layout
+	for i, line := range lines {
+		switch i {
+		case 0:
+			layout.Username = line
+		case 1:
+			layout.DisplayName = line
+		default:
+			layout.Extra = append(layout.Extra, line)
+		}
+	}
+
+	return layout, nil
+}
+
+func (a *RowLayoutAnalyzer) getLines(screenshotMat gocv.Mat, textRect image.Rectangle) ([]image.Rectangle, error) {
+	background, err := util.GetBackgroundLevel(screenshotMat, textRect)
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "failed to get background level")
+	}
+
+	rowsProfile, _, err := util.GetProjectionProfiles(screenshotMat, textRect, background, a.config.RowLayoutInkThreshold)
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "failed to get projection profiles")
+	}
+
+	var lines []image.Rectangle
+	runs := util.FindRuns(rowsProfile, minInkPerLine, a.config.RowLayoutMaxLineGap, a.config.RowLayoutMinLineHeight)
+	for _, run := range runs {
+		bandRect := image.Rect(textRect.Min.X, textRect.Min.Y+run.Start, textRect.Max.X, textRect.Min.Y+run.End)
+		_, colsProfile, err := util.GetProjectionProfiles(screenshotMat, bandRect, background, a.config.RowLayoutInkThreshold)
+		if err != nil {
+			return nil, stacktrace.Propagate(err, "failed to get projection profiles of line %v", bandRect)
+		}
+
+		// Words are separated by wide gaps, so the whole band width is allowed as a gap
+		colRuns := util.FindRuns(colsProfile, minInkPerLine, len(colsProfile), 1)
+		if len(colRuns) == 0 {
+			continue
+		}
+
+		lines = append(lines, image.Rect(
+			bandRect.Min.X+colRuns[0].Start, bandRect.Min.Y,
+			bandRect.Min.X+colRuns[len(colRuns)-1].End, bandRect.Max.Y,
+		))
+	}
+
+	return lines, nil
+}
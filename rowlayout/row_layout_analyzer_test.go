@@ -0,0 +1,112 @@
+package rowlayout_test
+
+import (
+	"image"
+	"testing"
+
+	"github.com/rogeriofbrito/go-insta-scraper-v2/config"
+	"github.com/rogeriofbrito/go-insta-scraper-v2/rowlayout"
+	"gocv.io/x/gocv"
+)
+
+func TestRowLayoutAnalyzer_Analyze_DiverseCases(t *testing.T) {
+	// Allowed distance between expected and found line edges, to absorb anti-aliasing
+	const tolerance = 2
+
+	tests := []struct {
+		name          string
+		imagePath     string
+		flags         gocv.IMReadFlag
+		textRect      image.Rectangle
+		expectedLines []image.Rectangle // username, display name and extra lines
+	}{
+		{
+			name:      "two_lines_dark_mode_row",
+			imagePath: "testdata/two_lines_row.png",
+			flags:     gocv.IMReadColor,
+			textRect:  image.Rect(165, 0, 605, 150),
+			expectedLines: []image.Rectangle{
+				image.Rect(176, 41, 430, 70),
+				image.Rect(177, 82, 383, 104),
+			},
+		},
+		{
+			name:      "two_lines_dark_mode_row_grayscale",
+			imagePath: "testdata/two_lines_row.png",
+			flags:     gocv.IMReadGrayScale,
+			textRect:  image.Rect(165, 0, 605, 150),
+			expectedLines: []image.Rectangle{
+				image.Rect(176, 41, 430, 70),
+				image.Rect(177, 82, 383, 104),
+			},
+		},
+		{
+			name:      "three_lines_highlighted_row_merges_accents",
+			imagePath: "testdata/three_lines_highlighted.png",
+			flags:     gocv.IMReadGrayScale,
+			textRect:  image.Rect(165, 0, 600, 150),
+			expectedLines: []image.Rectangle{
+				image.Rect(170, 30, 326, 58),
+				image.Rect(170, 64, 396, 92),
+				image.Rect(170, 104, 516, 122),
+			},
+		},
+		{
+			name:      "single_line_row_ignores_noise",
+			imagePath: "testdata/single_line.png",
+			flags:     gocv.IMReadColor,
+			textRect:  image.Rect(165, 0, 600, 150),
+			expectedLines: []image.Rectangle{
+				image.Rect(170, 60, 286, 88),
+			},
+		},
+		{
+			name:          "row_without_text",
+			imagePath:     "testdata/single_line.png",
+			flags:         gocv.IMReadColor,
+			textRect:      image.Rect(0, 0, 160, 150),
+			expectedLines: nil,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			imageMat := gocv.IMRead(tc.imagePath, tc.flags)
+			if imageMat.Empty() {
+				t.Fatalf("failed to load image: %s", tc.imagePath)
+			}
+			defer imageMat.Close()
+
+			cfg := config.Config{
+				RowLayoutInkThreshold:  40,
+				RowLayoutMinLineHeight: 8,
+				RowLayoutMaxLineGap:    3,
+			}
+			a := rowlayout.NewRowLayoutAnalyzer(&cfg)
+
+			layout, err := a.Analyze(imageMat, tc.textRect)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			lines := layout.Lines()
+			if len(lines) != len(tc.expectedLines) {
+				t.Fatalf("Analyze(%s) lines = %v; expected %v", tc.imagePath, lines, tc.expectedLines)
+			}
+			for i, line := range lines {
+				expected := tc.expectedLines[i]
+				if abs(line.Min.X-expected.Min.X) > tolerance || abs(line.Min.Y-expected.Min.Y) > tolerance ||
+					abs(line.Max.X-expected.Max.X) > tolerance || abs(line.Max.Y-expected.Max.Y) > tolerance {
+					t.Errorf("Analyze(%s) line %d = %v; expected %v", tc.imagePath, i, line, expected)
+				}
+			}
+		})
+	}
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,50 @@
+package screenshotuserextractor
+
+import (
+	"github.com/rogeriofbrito/go-insta-scraper-v2/avatardetector"
+	"github.com/rogeriofbrito/go-insta-scraper-v2/avatarhash"
+	"github.com/rogeriofbrito/go-insta-scraper-v2/config"
+	"github.com/rogeriofbrito/go-insta-scraper-v2/occlusion"
+	"github.com/rogeriofbrito/go-insta-scraper-v2/ocr"
+	"github.com/rogeriofbrito/go-insta-scraper-v2/rowattributes"
+	"github.com/rogeriofbrito/go-insta-scraper-v2/rowlayout"
+	"github.com/rogeriofbrito/go-insta-scraper-v2/sectionheader"
+	"github.com/rogeriofbrito/go-insta-scraper-v2/spinnerdetector"
+	"github.com/rogeriofbrito/go-insta-scraper-v2/templatematcher"
+	"github.com/rogeriofbrito/go-insta-scraper-v2/tesseractocr"
+	"github.com/rogeriofbrito/go-insta-scraper-v2/usernamebox"
+)
+
+// Dependencies are the collaborators a ScreenshotUserExtractor delegates to. They hold no per screenshot
+// state, so the extractors of a batch can share them.
+type Dependencies struct {
+	TemplateMatcher       *templatematcher.TemplateMatcher
+	AvatarDetector        *avatardetector.AvatarDetector
+	RowLayoutAnalyzer     *rowlayout.RowLayoutAnalyzer
+	UsernameBoxRefiner    *usernamebox.UsernameBoxRefiner
+	RowAttributesDetector *rowattributes.RowAttributesDetector
+	AvatarHasher          *avatarhash.AvatarHasher
+	SectionHeaderDetector *sectionheader.SectionHeaderDetector
+	OcclusionDetector     *occlusion.OcclusionDetector
+	SpinnerDetector       *spinnerdetector.SpinnerDetector
+	UsernameOcr           ocr.Engine // Reads usernames
+	DisplayNameOcr        ocr.Engine // Reads display names, which are not limited to the username characters
+}
+
+// NewDependencies creates the collaborators of a ScreenshotUserExtractor from config, reading text with
+// Tesseract. Replace single fields to change a collaborator, e.g. the OCR engines in tests.
+func NewDependencies(config *config.Config) Dependencies {
+	return Dependencies{
+		TemplateMatcher:       templatematcher.NewTemplateMatcher(config),
+		AvatarDetector:        avatardetector.NewAvatarDetector(config),
+		RowLayoutAnalyzer:     rowlayout.NewRowLayoutAnalyzer(config),
+		UsernameBoxRefiner:    usernamebox.NewUsernameBoxRefiner(config),
+		RowAttributesDetector: rowattributes.NewRowAttributesDetector(config),
+		AvatarHasher:          avatarhash.NewAvatarHasher(config),
+		SectionHeaderDetector: sectionheader.NewSectionHeaderDetector(config, tesseractocr.NewSectionHeaderTesseractOcr(config)),
+		OcclusionDetector:     occlusion.NewOcclusionDetector(config),
+		SpinnerDetector:       spinnerdetector.NewSpinnerDetector(config),
+		UsernameOcr:           tesseractocr.NewTesseractOcr(config),
+		DisplayNameOcr:        tesseractocr.NewDisplayNameTesseractOcr(config),
+	}
+}
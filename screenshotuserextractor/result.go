@@ -0,0 +1,56 @@
+package screenshotuserextractor
+
+import (
+	"image"
+
+	"github.com/rogeriofbrito/go-insta-scraper-v2/ocr"
+	"github.com/rogeriofbrito/go-insta-scraper-v2/templatematcher"
+	"github.com/rogeriofbrito/go-insta-scraper-v2/usernamegrammar"
+)
+
+// Names of the button templates, used as keys of Result.TemplateStats.
+const (
+	TemplateFollow    = "follow"
+	TemplateFollowing = "following"
+	TemplateMessage   = "message"
+)
+
+// Result holds everything extracted from a single screenshot.
+type Result struct {
+	Rows                  []Row                                 // User rows, from top to bottom
+	TemplateStats         map[string]templatematcher.MatchStats // Best match of each template, keyed by template name
+	Warnings              []string                              // Problems found that did not prevent the extraction
+	StartOfList           bool                                  // Whether the topmost row is the first row of the list (see ListTopReferenceY), so the list is scrolled to the top; always true when ListTopReferenceY is 0
+	EndOfList             bool                                  // Whether the "Suggested for you" header, which ends the list, is in the screenshot; false unless DetectSuggestionsHeader is set
+	SuggestionsHeaderRect image.Rectangle                       // Bounding box of the "Suggested for you" header text, empty if EndOfList is false
+	Occlusions            []image.Rectangle                     // Overlays masked out of the screenshot (banners, search bar, keyboard, toasts), empty unless DetectOcclusions is set
+	Spinner               bool                                  // Whether the loading spinner is in the screenshot, so more accounts were loading; false unless DetectSpinner is set
+	SpinnerRect           image.Rectangle                       // Bounding box of the loading spinner, empty if Spinner is false
+}
+
+// Row holds the data extracted from a single user row of the screenshot.
+type Row struct {
+	ReferencePoint     image.Point            // Reference point (button min point) the row was located from
+	UsernameRect       image.Rectangle        // Region of the screenshot where the username was searched, read by OCR unless refined
+	UsernameTextRect   image.Rectangle        // Bounding box of the username text, empty if RefineUsernameRect is not set or no text was found
+	Username           string                 // Username read by OCR, corrected when UsernameStatus is corrected; empty if an inferred row has no text or the read is invalid
+	UsernameRaw        string                 // Username as read by OCR, before validation
+	UsernameStatus     usernamegrammar.Status // Outcome of checking UsernameRaw against the username grammar, unchecked unless ValidateUsernames is set
+	UsernameConfidence float64                // OCR confidence of Username, from 0 to 100, 0 when no username was read
+	UsernameWords      []ocr.Word             // Words Username was read from, with their confidences and character boxes
+	NeedsReview        bool                   // Whether a word of Username was read with a confidence below UsernameReviewConfidence, so it is not trusted
+	Truncated          bool                   // Whether the username is cut off by an ellipsis or by the button, so Username is only its beginning
+	Partial            bool                   // Whether the row is cut off by the screenshot edge, in which case Username is not read and left empty
+	Occluded           bool                   // Whether the username rect is covered by an overlay, in which case Username is not read and left empty
+	DisplayNameRect    image.Rectangle        // Region of the line below the username, empty if the row has a single line, no TextRect is configured or the row is partial or occluded
+	DisplayName        string                 // Display name read by OCR, empty if the row has none or it could not be read
+	ExtraLineRects     []image.Rectangle      // Regions of the lines below the display name (e.g. "Followed by…")
+	AvatarRect         image.Rectangle        // Region of the profile picture, placed as in the sample position
+	Verified           bool                   // Whether the account has the verified badge, false unless DetectRowAttributes is set
+	StoryRing          bool                   // Whether the profile picture has the ring of an active story, false unless DetectRowAttributes is set
+	AvatarHash         uint64                 // Perceptual hash of the profile picture (see avatarhash), valid if AvatarHashed
+	AvatarHashed       bool                   // Whether AvatarHash was computed: HashAvatars is set and the profile picture is inside the screenshot
+	AvatarPath         string                 // Path of the saved profile picture crop, empty if AvatarCropDirPath is not set
+	Inferred           bool                   // Whether the row was inferred from the row spacing instead of a matched button
+	Suggested          bool                   // Whether the row is below the "Suggested for you" header, so the account is not part of the list
+}
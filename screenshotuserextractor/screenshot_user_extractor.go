@@ -1,15 +1,27 @@
 package screenshotuserextractor
 
 import (
+	"context"
 	"fmt"
 	"image"
+	"math"
 	"os"
+	"path/filepath"
 	"strings"
 
 	"github.com/palantir/stacktrace"
+	"github.com/rogeriofbrito/go-insta-scraper-v2/avatardetector"
+	"github.com/rogeriofbrito/go-insta-scraper-v2/avatarhash"
 	"github.com/rogeriofbrito/go-insta-scraper-v2/config"
+	"github.com/rogeriofbrito/go-insta-scraper-v2/occlusion"
+	"github.com/rogeriofbrito/go-insta-scraper-v2/ocr"
+	"github.com/rogeriofbrito/go-insta-scraper-v2/rowattributes"
+	"github.com/rogeriofbrito/go-insta-scraper-v2/rowlayout"
+	"github.com/rogeriofbrito/go-insta-scraper-v2/sectionheader"
+	"github.com/rogeriofbrito/go-insta-scraper-v2/spinnerdetector"
 	"github.com/rogeriofbrito/go-insta-scraper-v2/templatematcher"
-	"github.com/rogeriofbrito/go-insta-scraper-v2/tesseractocr"
+	"github.com/rogeriofbrito/go-insta-scraper-v2/usernamebox"
+	"github.com/rogeriofbrito/go-insta-scraper-v2/usernamegrammar"
 	"github.com/rogeriofbrito/go-insta-scraper-v2/util"
 	"gocv.io/x/gocv"
 )
@@ -20,8 +32,7 @@ func NewScreenshotUserExtractor(
 	templateFollowingPath string,
 	templateMessagePath string,
 	config *config.Config,
-	tm *templatematcher.TemplateMatcher,
-	tocr *tesseractocr.TesseractOcr,
+	deps Dependencies,
 ) *ScreenshotUserExtractor {
 	return &ScreenshotUserExtractor{
 		screenshotPath:        screenshotPath,
@@ -29,8 +40,17 @@ func NewScreenshotUserExtractor(
 		templateFollowingPath: templateFollowingPath,
 		templateMessagePath:   templateMessagePath,
 		config:                config,
-		tm:                    tm,
-		tocr:                  tocr,
+		tm:                    deps.TemplateMatcher,
+		ad:                    deps.AvatarDetector,
+		ra:                    deps.RowLayoutAnalyzer,
+		ubr:                   deps.UsernameBoxRefiner,
+		rad:                   deps.RowAttributesDetector,
+		ah:                    deps.AvatarHasher,
+		shd:                   deps.SectionHeaderDetector,
+		od:                    deps.OcclusionDetector,
+		sd:                    deps.SpinnerDetector,
+		tocr:                  deps.UsernameOcr,
+		dnocr:                 deps.DisplayNameOcr,
 	}
 }
 
@@ -41,26 +61,64 @@ type ScreenshotUserExtractor struct {
 	templateMessagePath   string
 	config                *config.Config
 	tm                    *templatematcher.TemplateMatcher
-	tocr                  *tesseractocr.TesseractOcr
+	ad                    *avatardetector.AvatarDetector
+	ra                    *rowlayout.RowLayoutAnalyzer
+	ubr                   *usernamebox.UsernameBoxRefiner
+	rad                   *rowattributes.RowAttributesDetector
+	ah                    *avatarhash.AvatarHasher
+	shd                   *sectionheader.SectionHeaderDetector
+	od                    *occlusion.OcclusionDetector
+	sd                    *spinnerdetector.SpinnerDetector
+	tocr                  ocr.Engine
+	dnocr                 ocr.Engine // Reads display names, which are not limited to the username characters
 }
 
-func (s *ScreenshotUserExtractor) GetUsernames() ([]string, error) {
+// GetUsernames returns the usernames found in the screenshot, from top to bottom. Truncated usernames
+// are left out, since they are only a prefix of the handle, as are suggested accounts, which are not
+// part of the list, usernames OCR is not sure about, which need review, and reads that break the username
+// grammar.
+// Use Extract to also get the positions and matching statistics behind each username.
+func (s *ScreenshotUserExtractor) GetUsernames(ctx context.Context) ([]string, error) {
+	result, err := s.Extract(ctx)
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "failed to extract users from screenshot")
+	}
+
+	var usernames []string
+	for _, row := range result.Rows {
+		// Inferred rows may not hold a user at all (e.g. a list header)
+		if row.Username == "" {
+			continue
+		}
+		if row.Truncated || row.Suggested || row.NeedsReview || row.UsernameStatus == usernamegrammar.StatusInvalid {
+			continue
+		}
+		usernames = append(usernames, row.Username)
+	}
+
+	return usernames, nil
+}
+
+// Extract reads every user row of the screenshot and returns them, from top to bottom,
+// along with the best match statistics of each template. Extraction stops with an error once ctx is done,
+// killing any running OCR process.
+func (s *ScreenshotUserExtractor) Extract(ctx context.Context) (*Result, error) {
 	mtScreenshotMat, err := s.readImage(s.screenshotPath, s.config.MatchTemplateImageFlags)
 	if err != nil {
 		return nil, stacktrace.Propagate(err, "failed to read screenshot image")
 	}
 
-	mtTemplateFollowMat, err := s.readImage(s.templateFollowPath, s.config.MatchTemplateImageFlags)
+	mtTemplateFollowMat, mtTemplateFollowMaskMat, err := s.readTemplate(s.templateFollowPath)
 	if err != nil {
 		return nil, stacktrace.Propagate(err, "failed to read follow template image")
 	}
 
-	mtTemplateFollowingMat, err := s.readImage(s.templateFollowingPath, s.config.MatchTemplateImageFlags)
+	mtTemplateFollowingMat, mtTemplateFollowingMaskMat, err := s.readTemplate(s.templateFollowingPath)
 	if err != nil {
 		return nil, stacktrace.Propagate(err, "failed to read following template image")
 	}
 
-	mtTemplateMessageMat, err := s.readImage(s.templateMessagePath, s.config.MatchTemplateImageFlags)
+	mtTemplateMessageMat, mtTemplateMessageMaskMat, err := s.readTemplate(s.templateMessagePath)
 	if err != nil {
 		return nil, stacktrace.Propagate(err, "failed to read message template image")
 	}
@@ -74,31 +132,147 @@ func (s *ScreenshotUserExtractor) GetUsernames() ([]string, error) {
 	defer mtTemplateFollowMat.Close()
 	defer mtTemplateFollowingMat.Close()
 	defer mtTemplateMessageMat.Close()
+	defer mtTemplateFollowMaskMat.Close()
+	defer mtTemplateFollowingMaskMat.Close()
+	defer mtTemplateMessageMaskMat.Close()
+	defer ocrScreenshotMat.Close()
+
+	occlusions, err := s.getOcclusions(&mtScreenshotMat, &ocrScreenshotMat)
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "failed to mask occlusions")
+	}
 
-	matches, err := s.getMatches(mtScreenshotMat, mtTemplateFollowMat, mtTemplateFollowingMat, mtTemplateMessageMat)
+	matches, templateStats, err := s.getMatches(
+		ctx,
+		mtScreenshotMat,
+		mtTemplateFollowMat, mtTemplateFollowMaskMat,
+		mtTemplateFollowingMat, mtTemplateFollowingMaskMat,
+		mtTemplateMessageMat, mtTemplateMessageMaskMat,
+	)
 	if err != nil {
 		return nil, stacktrace.Propagate(err, "failed to get matches")
 	}
 
-	minPoints := util.GetMinPointsFromRects(matches)
-	minPointsSecure := util.GetPointsInsideRect(minPoints, s.config.ReferencePointsSearchRect)
+	referencePointsX, referencePointsSearchRect, warnings := s.getReferencePointsPlacement(matches)
+
+	minPointsSecure, err := s.getRowMinPoints(mtScreenshotMat, matches, referencePointsSearchRect)
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "failed to locate rows")
+	}
+
 	yCoordinates := util.GetYCoordinatesFromPoints(minPointsSecure)
 	yCoordinatesGroup := util.GroupAverages(yCoordinates, s.config.GroupAveragesThreshold)
 	yCoordinatesGroupInt := util.ConvertSliceFloat64ToInt(yCoordinatesGroup)
-	referencePoints := util.GetReferencePoints(s.config.ReferencePointsXCoordinate, yCoordinatesGroupInt)
-	usernameRects := s.getUsernameRects(mtScreenshotMat, referencePoints)
+	inferred := make([]bool, len(yCoordinatesGroupInt))
+	if s.config.InferMissingRows {
+		yCoordinatesGroupInt, inferred = util.InferMissingCoordinates(yCoordinatesGroupInt, s.config.SamplePosition.AvatarRect.Dy(), s.config.RowSpacingTolerance)
+	}
+	referencePoints := util.GetReferencePoints(referencePointsX, yCoordinatesGroupInt)
+	layouts, partial, err := s.getRowLayouts(mtScreenshotMat, referencePoints)
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "failed to get row layouts")
+	}
 
-	usernameImagePaths, err := s.writeUsernameImages(ocrScreenshotMat, usernameRects)
+	var usernameRects []image.Rectangle
+	for _, layout := range layouts {
+		usernameRects = append(usernameRects, layout.Username)
+	}
+
+	occluded := getOccludedRows(usernameRects, occlusions)
+	skipped := make([]bool, len(referencePoints))
+	for i, referencePoint := range referencePoints {
+		if partial[i] {
+			warnings = append(warnings, fmt.Sprintf("row at %v is partially visible, its username is not read", referencePoint))
+		}
+		if occluded[i] {
+			warnings = append(warnings, fmt.Sprintf("row at %v is occluded by an overlay, its username is not read", referencePoint))
+		}
+		skipped[i] = partial[i] || occluded[i]
+	}
+
+	usernameBoxes, err := s.getUsernameBoxes(mtScreenshotMat, usernameRects, referencePoints)
 	if err != nil {
-		return nil, stacktrace.Propagate(err, "failed to write username images")
+		return nil, stacktrace.Propagate(err, "failed to refine username rects")
 	}
 
-	usernames, err := s.ocrUsernames(usernameImagePaths)
+	ocrUsernameRects := s.getOcrUsernameRects(mtScreenshotMat, usernameRects, usernameBoxes, skipped)
+	usernames, usernameResults, err := s.ocrUsernames(ctx, ocrScreenshotMat, ocrUsernameRects, inferred)
 	if err != nil {
 		return nil, stacktrace.Propagate(err, "failed to read usernames from screenshot")
 	}
 
-	return usernames, nil
+	needsReview, reviewWarnings := s.getUsernamesToReview(usernames, usernameResults, referencePoints)
+	warnings = append(warnings, reviewWarnings...)
+
+	validations, validationWarnings := s.validateUsernames(usernames, usernameBoxes, referencePoints)
+	warnings = append(warnings, validationWarnings...)
+
+	displayNameRects, displayNames, err := s.ocrDisplayNames(ctx, ocrScreenshotMat, layouts, skipped)
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "failed to read display names from screenshot")
+	}
+
+	avatarRects := s.getAvatarRects(referencePoints)
+	attributes, attributeWarnings, err := s.getRowAttributes(mtScreenshotMat, usernameRects, usernameBoxes, avatarRects)
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "failed to detect row attributes")
+	}
+	warnings = append(warnings, attributeWarnings...)
+
+	avatars, err := s.getAvatars(mtScreenshotMat, avatarRects)
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "failed to hash avatars")
+	}
+
+	suggestionsHeader, endOfList, err := s.getSuggestionsHeader(ctx, ocrScreenshotMat, getRowRects(avatarRects, layouts))
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "failed to find suggestions header")
+	}
+
+	spinnerRect, spinner, err := s.getSpinner(mtScreenshotMat)
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "failed to find loading spinner")
+	}
+
+	result := &Result{
+		TemplateStats:         templateStats,
+		Warnings:              warnings,
+		StartOfList:           s.isStartOfList(referencePoints),
+		EndOfList:             endOfList,
+		SuggestionsHeaderRect: suggestionsHeader.Rect,
+		Occlusions:            occlusions,
+		Spinner:               spinner,
+		SpinnerRect:           spinnerRect,
+	}
+	for i, username := range usernames {
+		result.Rows = append(result.Rows, Row{
+			ReferencePoint:     referencePoints[i],
+			UsernameRect:       usernameRects[i],
+			UsernameTextRect:   usernameBoxes[i].Rect,
+			Username:           validations[i].Username,
+			UsernameRaw:        username,
+			UsernameStatus:     validations[i].Status,
+			UsernameConfidence: usernameResults[i].Confidence,
+			UsernameWords:      usernameResults[i].Words,
+			NeedsReview:        needsReview[i],
+			Truncated:          usernameBoxes[i].Truncated,
+			Partial:            partial[i],
+			Occluded:           occluded[i],
+			AvatarRect:         avatarRects[i],
+			Verified:           attributes[i].verified,
+			StoryRing:          attributes[i].storyRing,
+			AvatarHash:         avatars[i].hash,
+			AvatarHashed:       avatars[i].hashed,
+			AvatarPath:         avatars[i].path,
+			DisplayNameRect:    displayNameRects[i],
+			DisplayName:        displayNames[i],
+			ExtraLineRects:     layouts[i].Extra,
+			Inferred:           inferred[i],
+			Suggested:          endOfList && referencePoints[i].Y >= suggestionsHeader.Rect.Min.Y,
+		})
+	}
+
+	return result, nil
 }
 
 func (s *ScreenshotUserExtractor) readImage(imagePath string, flags gocv.IMReadFlag) (gocv.Mat, error) {
@@ -110,100 +284,627 @@ func (s *ScreenshotUserExtractor) readImage(imagePath string, flags gocv.IMReadF
 	return imageMat, nil
 }
 
+// readTemplate reads a template image keeping its alpha channel, if any, and returns the
+// template converted according to MatchTemplateImageFlags along with its transparency mask.
+// The mask is empty when the template has no transparent pixels.
+func (s *ScreenshotUserExtractor) readTemplate(templatePath string) (gocv.Mat, gocv.Mat, error) {
+	unchangedMat, err := s.readImage(templatePath, gocv.IMReadUnchanged)
+	if err != nil {
+		return gocv.Mat{}, gocv.Mat{}, stacktrace.Propagate(err, "failed to read template image")
+	}
+	defer unchangedMat.Close()
+
+	if unchangedMat.Channels() != 4 {
+		templateMat, err := s.readImage(templatePath, s.config.MatchTemplateImageFlags)
+		if err != nil {
+			return gocv.Mat{}, gocv.Mat{}, stacktrace.Propagate(err, "failed to read template image")
+		}
+
+		return templateMat, gocv.NewMat(), nil
+	}
+
+	templateMat, maskMat, err := util.SplitAlphaMask(unchangedMat)
+	if err != nil {
+		return gocv.Mat{}, gocv.Mat{}, stacktrace.Propagate(err, "failed to split template alpha channel at %s", templatePath)
+	}
+
+	if s.config.MatchTemplateImageFlags == gocv.IMReadGrayScale {
+		err = gocv.CvtColor(templateMat, &templateMat, gocv.ColorBGRToGray)
+		if err != nil {
+			templateMat.Close()
+			maskMat.Close()
+			return gocv.Mat{}, gocv.Mat{}, stacktrace.Propagate(err, "failed to convert template at %s to grayscale", templatePath)
+		}
+	}
+
+	return templateMat, maskMat, nil
+}
+
 func (s *ScreenshotUserExtractor) getMatches(
+	ctx context.Context,
 	screenshotMat,
-	templateFollowMat,
-	templateFollowingMat,
-	templateMessageMat gocv.Mat,
-) ([]image.Rectangle, error) {
+	templateFollowMat, templateFollowMaskMat,
+	templateFollowingMat, templateFollowingMaskMat,
+	templateMessageMat, templateMessageMaskMat gocv.Mat,
+) ([]image.Rectangle, map[string]templatematcher.MatchStats, error) {
 	var matches []image.Rectangle
 
-	matchesFollow, err := s.tm.GetMatches(screenshotMat, templateFollowMat)
+	matchesFollow, statsFollow, err := s.tm.GetMatchesWithStats(ctx, screenshotMat, templateFollowMat, templateFollowMaskMat)
 	if err != nil {
-		return nil, stacktrace.Propagate(err, "failed to get follow buttom matches")
+		return nil, nil, stacktrace.Propagate(err, "failed to get follow buttom matches")
 	}
 
-	matchesFollowing, err := s.tm.GetMatches(screenshotMat, templateFollowingMat)
+	matchesFollowing, statsFollowing, err := s.tm.GetMatchesWithStats(ctx, screenshotMat, templateFollowingMat, templateFollowingMaskMat)
 	if err != nil {
-		return nil, stacktrace.Propagate(err, "failed to get following buttom matches")
+		return nil, nil, stacktrace.Propagate(err, "failed to get following buttom matches")
 	}
 
-	matchesMessage, err := s.tm.GetMatches(screenshotMat, templateMessageMat)
+	matchesMessage, statsMessage, err := s.tm.GetMatchesWithStats(ctx, screenshotMat, templateMessageMat, templateMessageMaskMat)
 	if err != nil {
-		return nil, stacktrace.Propagate(err, "failed to get message buttom matches")
+		return nil, nil, stacktrace.Propagate(err, "failed to get message buttom matches")
 	}
 
 	matches = append(matches, matchesFollow...)
 	matches = append(matches, matchesFollowing...)
 	matches = append(matches, matchesMessage...)
 
-	return matches, nil
+	templateStats := map[string]templatematcher.MatchStats{
+		TemplateFollow:    statsFollow,
+		TemplateFollowing: statsFollowing,
+		TemplateMessage:   statsMessage,
+	}
+
+	return matches, templateStats, nil
 }
 
-func (s *ScreenshotUserExtractor) getUsernameRects(screenshotMat gocv.Mat, referencePoints []image.Point) []image.Rectangle {
-	baseTopCenterUsernameRect := s.config.SamplePosition.TopCenterUsernameRect.Sub(s.config.SamplePosition.ReferencePoint)
-	baseCenterUsernameRect := s.config.SamplePosition.CenterUsernameRect.Sub(s.config.SamplePosition.ReferencePoint)
-	baseUpUsernameRect := s.config.SamplePosition.UpUsernameRect.Sub(s.config.SamplePosition.ReferencePoint)
+// getReferencePointsPlacement returns the X coordinate of reference points and the area where they are
+// allowed to be in. Both come from config unless AutoEstimateReferencePoints is set, in which case they
+// are estimated from the button matches inside the rows of the configured area, along with a warning for
+// each estimate that disagrees with its configured value.
+func (s *ScreenshotUserExtractor) getReferencePointsPlacement(matches []image.Rectangle) (int, image.Rectangle, []string) {
+	x, searchRect := s.config.ReferencePointsXCoordinate, s.config.ReferencePointsSearchRect
+	if !s.config.AutoEstimateReferencePoints {
+		return x, searchRect, nil
+	}
 
-	var usernameRects []image.Rectangle
-	for _, referencePoint := range referencePoints {
-		topCenterUsernameRect := baseTopCenterUsernameRect.Add(referencePoint)
-		if util.IsUniformRegion(screenshotMat, topCenterUsernameRect, s.config.UniformThresold) {
-			usernameRects = append(usernameRects, baseCenterUsernameRect.Add(referencePoint))
-		} else {
-			usernameRects = append(usernameRects, baseUpUsernameRect.Add(referencePoint))
+	tolerance := s.config.ReferencePointsEstimateTolerance
+	minPoints := util.GetMinPointsFromRects(matches)
+	estimatedX, estimatedSearchRect, found := util.EstimateReferencePointsPlacement(minPoints, searchRect, tolerance)
+	if !found {
+		return x, searchRect, []string{"no button matches to estimate reference points from, using configured values"}
+	}
+
+	var warnings []string
+	if math.Abs(float64(estimatedX-x)) > float64(tolerance) {
+		warnings = append(warnings, fmt.Sprintf(
+			"estimated reference points X coordinate %d differs from configured %d", estimatedX, x))
+	}
+	if !estimatedSearchRect.In(searchRect) {
+		warnings = append(warnings, fmt.Sprintf(
+			"estimated reference points search rect %v is not inside configured %v", estimatedSearchRect, searchRect))
+	}
+
+	return estimatedX, estimatedSearchRect, warnings
+}
+
+// getRowMinPoints returns a reference point candidate for each row located according to RowLocator:
+// the min point of every button match inside searchRect and/or the point derived from every profile
+// picture, placed relative to it as in the sample position.
+func (s *ScreenshotUserExtractor) getRowMinPoints(screenshotMat gocv.Mat, matches []image.Rectangle, searchRect image.Rectangle) ([]image.Point, error) {
+	var minPoints []image.Point
+
+	if s.config.RowLocator != config.RowLocatorAvatars {
+		buttonMinPoints := util.GetMinPointsFromRects(matches)
+		minPoints = append(minPoints, util.GetPointsInsideRect(buttonMinPoints, searchRect)...)
+	}
+
+	if s.config.RowLocator != config.RowLocatorButtons {
+		avatars, err := s.ad.GetAvatars(screenshotMat)
+		if err != nil {
+			return nil, stacktrace.Propagate(err, "failed to get avatars")
+		}
+
+		// Centers are used instead of min points because the avatar radius changes with the story ring
+		sampleAvatarRect := s.config.SamplePosition.AvatarRect
+		sampleAvatarCenter := sampleAvatarRect.Min.Add(sampleAvatarRect.Max).Div(2)
+		avatarOffset := sampleAvatarCenter.Sub(s.config.SamplePosition.ReferencePoint)
+		for _, avatar := range avatars {
+			avatarCenter := avatar.Min.Add(avatar.Max).Div(2)
+			minPoints = append(minPoints, avatarCenter.Sub(avatarOffset))
 		}
 	}
 
-	return usernameRects
+	return minPoints, nil
 }
 
-func (s *ScreenshotUserExtractor) writeUsernameImages(screenshotMat gocv.Mat, usernameRects []image.Rectangle) ([]string, error) {
-	var usernameImagePaths []string
+// getRowLayouts returns the text lines of each row, padded by RowLayoutLinePadding, and whether each row
+// is partially visible. Rows are analyzed inside the sample TextRect; without it, or when a row has no
+// text line, the username rect is chosen between the centered and up rects of the sample position,
+// depending on whether the region above the centered one is uniform. Every rect is clipped to the
+// screenshot, and a row is partial when its username may be cut off by the screenshot edge.
+func (s *ScreenshotUserExtractor) getRowLayouts(screenshotMat gocv.Mat, referencePoints []image.Point) ([]rowlayout.Layout, []bool, error) {
+	baseTextRect := s.config.SamplePosition.TextRect.Sub(s.config.SamplePosition.ReferencePoint)
+	bounds := image.Rect(0, 0, screenshotMat.Cols(), screenshotMat.Rows())
+
+	var layouts []rowlayout.Layout
+	partial := make([]bool, len(referencePoints))
+	for i, referencePoint := range referencePoints {
+		var layout rowlayout.Layout
+		fullTextRect := baseTextRect.Add(referencePoint)
+		textRect, textCut := util.ClipRect(fullTextRect, bounds)
+		if !baseTextRect.Empty() && !textRect.Empty() {
+
+			var err error
+			layout, err = s.ra.Analyze(screenshotMat, textRect)
+			if err != nil {
+				return nil, nil, stacktrace.Propagate(err, "failed to analyze layout of row at %v", referencePoint)
+			}
+
+			// A username line touching a clipped edge goes on beyond it. When the top is clipped past the
+			// sample username, the username may be entirely hidden and the first line found be another one.
+			if textCut {
+				username := layout.Username
+				partial[i] = (textRect.Min.Y > fullTextRect.Min.Y &&
+					(username.Min.Y <= textRect.Min.Y || s.getUsernameZone(referencePoint).Min.Y < textRect.Min.Y)) ||
+					(textRect.Max.Y < fullTextRect.Max.Y && !username.Empty() && username.Max.Y >= textRect.Max.Y)
+			}
+
+			padLine := func(line image.Rectangle) image.Rectangle {
+				if line.Empty() {
+					return line
+				}
+				return line.Inset(-s.config.RowLayoutLinePadding).Intersect(textRect)
+			}
+			layout.Username = padLine(layout.Username)
+			layout.DisplayName = padLine(layout.DisplayName)
+			for i := range layout.Extra {
+				layout.Extra[i] = padLine(layout.Extra[i])
+			}
+		}
+
+		if layout.Username.Empty() {
+			var usernameCut bool
+			layout.Username, usernameCut = util.ClipRect(s.getFallbackUsernameRect(screenshotMat, referencePoint), bounds)
+			partial[i] = partial[i] || usernameCut
+		}
+
+		layouts = append(layouts, layout)
+	}
+
+	return layouts, partial, nil
+}
+
+// getUsernameZone returns the region of the row at referencePoint where the username can be, according
+// to the username rects of the sample position.
+func (s *ScreenshotUserExtractor) getUsernameZone(referencePoint image.Point) image.Rectangle {
+	samplePosition := s.config.SamplePosition
+	usernameZone := samplePosition.TopCenterUsernameRect.Union(samplePosition.CenterUsernameRect).Union(samplePosition.UpUsernameRect)
+
+	return usernameZone.Sub(samplePosition.ReferencePoint).Add(referencePoint)
+}
+
+// getUsernameBoxes returns the username text box of each row, searched from its username rect up to
+// the row button, or empty boxes when RefineUsernameRect is not set.
+func (s *ScreenshotUserExtractor) getUsernameBoxes(screenshotMat gocv.Mat, usernameRects []image.Rectangle, referencePoints []image.Point) ([]usernamebox.Box, error) {
+	usernameBoxes := make([]usernamebox.Box, len(usernameRects))
+	if !s.config.RefineUsernameRect {
+		return usernameBoxes, nil
+	}
+
 	for i, usernameRect := range usernameRects {
-		usernameMat := screenshotMat.Region(usernameRect)
-		usernameImagePath := fmt.Sprintf("%s/username_%d.jpg", s.config.WorkingDirPath, i)
+		usernameBox, err := s.ubr.Refine(screenshotMat, usernameRect, referencePoints[i].X)
+		if err != nil {
+			return nil, stacktrace.Propagate(err, "failed to refine username rect %v", usernameRect)
+		}
+
+		usernameBoxes[i] = usernameBox
+	}
+
+	return usernameBoxes, nil
+}
 
-		writeSuccess := gocv.IMWrite(usernameImagePath, usernameMat)
-		if !writeSuccess {
-			return nil, stacktrace.NewError("failed to write mat at path %s", usernameImagePath)
+// getOcrUsernameRects returns the region read by OCR for each row: the username text box padded by
+// UsernameRectPadding when there is one, the username rect otherwise. Skipped rows (partial or occluded
+// ones) get an empty region, since reading a hidden username would give a wrong one.
+func (s *ScreenshotUserExtractor) getOcrUsernameRects(
+	screenshotMat gocv.Mat,
+	usernameRects []image.Rectangle,
+	usernameBoxes []usernamebox.Box,
+	skipped []bool,
+) []image.Rectangle {
+	bounds := image.Rect(0, 0, screenshotMat.Cols(), screenshotMat.Rows())
+
+	var ocrUsernameRects []image.Rectangle
+	for i, usernameBox := range usernameBoxes {
+		if skipped[i] {
+			ocrUsernameRects = append(ocrUsernameRects, image.Rectangle{})
+			continue
+		}
+		if usernameBox.Rect.Empty() {
+			ocrUsernameRects = append(ocrUsernameRects, usernameRects[i])
+			continue
 		}
 
-		usernameImagePaths = append(usernameImagePaths, usernameImagePath)
+		ocrUsernameRects = append(ocrUsernameRects, usernameBox.Rect.Inset(-s.config.UsernameRectPadding).Intersect(bounds))
 	}
 
-	return usernameImagePaths, nil
+	return ocrUsernameRects
 }
 
-func (s *ScreenshotUserExtractor) ocrUsernames(usernameImagePaths []string) ([]string, error) {
-	var usernames []string
-	for _, usernameImagePath := range usernameImagePaths {
-		err := s.tocr.OCR(usernameImagePath, usernameImagePath)
+// getAvatarRects returns the profile picture rect of each row, placed relative to its reference point
+// as in the sample position.
+func (s *ScreenshotUserExtractor) getAvatarRects(referencePoints []image.Point) []image.Rectangle {
+	baseAvatarRect := s.config.SamplePosition.AvatarRect.Sub(s.config.SamplePosition.ReferencePoint)
+
+	var avatarRects []image.Rectangle
+	for _, referencePoint := range referencePoints {
+		avatarRects = append(avatarRects, baseAvatarRect.Add(referencePoint))
+	}
+
+	return avatarRects
+}
+
+type rowAttributes struct {
+	verified  bool
+	storyRing bool
+}
+
+// getRowAttributes detects the verified badge and the story ring of each row when DetectRowAttributes
+// is set. The badge is searched right of the username text box or, when there is none, in the whole
+// username rect. Both need colors, so detection is skipped with a warning when the screenshot was not read
+// as a color image (e.g. MatchTemplateImageFlags is grayscale).
+func (s *ScreenshotUserExtractor) getRowAttributes(
+	screenshotMat gocv.Mat,
+	usernameRects []image.Rectangle,
+	usernameBoxes []usernamebox.Box,
+	avatarRects []image.Rectangle,
+) ([]rowAttributes, []string, error) {
+	attributes := make([]rowAttributes, len(usernameRects))
+	if !s.config.DetectRowAttributes {
+		return attributes, nil, nil
+	}
+	if screenshotMat.Channels() != 3 {
+		return attributes, []string{fmt.Sprintf(
+			"row attributes not detected: they need a color screenshot, got %d channels (check MatchTemplateImageFlags)",
+			screenshotMat.Channels())}, nil
+	}
+
+	for i, usernameRect := range usernameRects {
+		badgeSearchRect := usernameRect
+		if textRect := usernameBoxes[i].Rect; !textRect.Empty() {
+			badgeSearchRect = image.Rect(textRect.Max.X, textRect.Min.Y, textRect.Max.X+s.config.BadgeSearchWidth, textRect.Max.Y)
+		}
+
+		verified, err := s.rad.HasVerifiedBadge(screenshotMat, badgeSearchRect)
+		if err != nil {
+			return nil, nil, stacktrace.Propagate(err, "failed to detect verified badge in %v", badgeSearchRect)
+		}
+
+		storyRing, err := s.rad.HasStoryRing(screenshotMat, avatarRects[i])
 		if err != nil {
-			return nil, stacktrace.Propagate(err,
-				"failed to execute tesseract ocr over %s with result at %s",
-				usernameImagePath, usernameImagePath+".txt")
+			return nil, nil, stacktrace.Propagate(err, "failed to detect story ring in %v", avatarRects[i])
 		}
 
-		usernameOcrTxtBytes, err := os.ReadFile(usernameImagePath + ".txt")
+		attributes[i] = rowAttributes{verified: verified, storyRing: storyRing}
+	}
+
+	return attributes, nil, nil
+}
+
+type avatar struct {
+	hash   uint64
+	hashed bool
+	path   string
+}
+
+// getAvatars hashes the profile picture of each row when HashAvatars is set and, when AvatarCropDirPath
+// is set, saves a crop of it there as <screenshot name>_avatar_<row>.png. Profile pictures not entirely
+// inside the screenshot are skipped.
+func (s *ScreenshotUserExtractor) getAvatars(screenshotMat gocv.Mat, avatarRects []image.Rectangle) ([]avatar, error) {
+	avatars := make([]avatar, len(avatarRects))
+	if !s.config.HashAvatars {
+		return avatars, nil
+	}
+
+	if s.config.AvatarCropDirPath != "" {
+		err := os.MkdirAll(s.config.AvatarCropDirPath, 0777)
 		if err != nil {
-			return nil, stacktrace.Propagate(err, "failed to read file %s", usernameImagePath+".txt")
+			return nil, stacktrace.Propagate(err, "failed to create avatar crop dir at path %s", s.config.AvatarCropDirPath)
+		}
+	}
+
+	screenshotName := strings.TrimSuffix(filepath.Base(s.screenshotPath), filepath.Ext(s.screenshotPath))
+	bounds := image.Rect(0, 0, screenshotMat.Cols(), screenshotMat.Rows())
+	for i, avatarRect := range avatarRects {
+		if !avatarRect.In(bounds) {
+			continue
+		}
+
+		hash, err := s.ah.Hash(screenshotMat, avatarRect)
+		if err != nil {
+			return nil, stacktrace.Propagate(err, "failed to hash avatar at %v", avatarRect)
+		}
+		avatars[i] = avatar{hash: hash, hashed: true}
+
+		if s.config.AvatarCropDirPath != "" {
+			avatarPath := filepath.Join(s.config.AvatarCropDirPath, fmt.Sprintf("%s_avatar_%d.png", screenshotName, i))
+			err = s.writeRegionImage(screenshotMat, avatarRect, avatarPath)
+			if err != nil {
+				return nil, stacktrace.Propagate(err, "failed to write avatar crop")
+			}
+			avatars[i].path = avatarPath
+		}
+	}
+
+	return avatars, nil
+}
+
+// getOcclusions finds the overlays of the match screenshot when DetectOcclusions is set and masks them
+// out of both the match and the OCR screenshots, which have the same size.
+func (s *ScreenshotUserExtractor) getOcclusions(mtScreenshotMat, ocrScreenshotMat *gocv.Mat) ([]image.Rectangle, error) {
+	if !s.config.DetectOcclusions {
+		return nil, nil
+	}
+
+	occlusions, err := s.od.Find(*mtScreenshotMat)
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "failed to find occlusions")
+	}
+
+	err = occlusion.Mask(mtScreenshotMat, occlusions)
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "failed to mask occlusions of match screenshot")
+	}
+
+	err = occlusion.Mask(ocrScreenshotMat, occlusions)
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "failed to mask occlusions of OCR screenshot")
+	}
+
+	return occlusions, nil
+}
+
+// getOccludedRows returns whether the username rect of each row overlaps an occlusion.
+func getOccludedRows(usernameRects []image.Rectangle, occlusions []image.Rectangle) []bool {
+	occluded := make([]bool, len(usernameRects))
+	for i, usernameRect := range usernameRects {
+		for _, occlusionRect := range occlusions {
+			if usernameRect.Overlaps(occlusionRect) {
+				occluded[i] = true
+				break
+			}
+		}
+	}
+
+	return occluded
+}
+
+// isStartOfList reports whether the topmost of referencePoints is where the first row of the list is when
+// the list is scrolled to the top, ListTopReferenceY. A scrolled list with a row there would also show the
+// button of the row above it. Always true when ListTopReferenceY is 0.
+func (s *ScreenshotUserExtractor) isStartOfList(referencePoints []image.Point) bool {
+	if s.config.ListTopReferenceY == 0 {
+		return true
+	}
+	if len(referencePoints) == 0 {
+		return false
+	}
+
+	return math.Abs(float64(referencePoints[0].Y-s.config.ListTopReferenceY)) <= float64(s.config.RowSpacingTolerance)
+}
+
+// getRowRects returns the area each row covers in the screenshot: its profile picture and its text lines.
+func getRowRects(avatarRects []image.Rectangle, layouts []rowlayout.Layout) []image.Rectangle {
+	rowRects := make([]image.Rectangle, len(avatarRects))
+	for i, avatarRect := range avatarRects {
+		rowRects[i] = avatarRect
+		for _, line := range layouts[i].Lines() {
+			rowRects[i] = rowRects[i].Union(line)
+		}
+	}
+
+	return rowRects
+}
+
+// getSuggestionsHeader returns the "Suggested for you" header of the screenshot when DetectSuggestionsHeader
+// is set, and whether it was found, which means the list ends in this screenshot. Only the text lines outside
+// rowRects are read.
+func (s *ScreenshotUserExtractor) getSuggestionsHeader(ctx context.Context, screenshotMat gocv.Mat, rowRects []image.Rectangle) (sectionheader.Header, bool, error) {
+	if !s.config.DetectSuggestionsHeader {
+		return sectionheader.Header{}, false, nil
+	}
+
+	header, found, err := s.shd.Find(ctx, screenshotMat, rowRects)
+	if err != nil {
+		return sectionheader.Header{}, false, stacktrace.Propagate(err, "failed to find section header")
+	}
+
+	return header, found, nil
+}
+
+// getSpinner returns the loading spinner of the screenshot when DetectSpinner is set, and whether it was found.
+func (s *ScreenshotUserExtractor) getSpinner(screenshotMat gocv.Mat) (image.Rectangle, bool, error) {
+	if !s.config.DetectSpinner {
+		return image.Rectangle{}, false, nil
+	}
+
+	spinnerRect, found, err := s.sd.Find(screenshotMat)
+	if err != nil {
+		return image.Rectangle{}, false, stacktrace.Propagate(err, "failed to find spinner")
+	}
+
+	return spinnerRect, found, nil
+}
+
+func (s *ScreenshotUserExtractor) getFallbackUsernameRect(screenshotMat gocv.Mat, referencePoint image.Point) image.Rectangle {
+	baseTopCenterUsernameRect := s.config.SamplePosition.TopCenterUsernameRect.Sub(s.config.SamplePosition.ReferencePoint)
+	baseCenterUsernameRect := s.config.SamplePosition.CenterUsernameRect.Sub(s.config.SamplePosition.ReferencePoint)
+	baseUpUsernameRect := s.config.SamplePosition.UpUsernameRect.Sub(s.config.SamplePosition.ReferencePoint)
+
+	topCenterUsernameRect := baseTopCenterUsernameRect.Add(referencePoint)
+	if util.IsUniformRegion(screenshotMat, topCenterUsernameRect, s.config.UniformThresold) {
+		return baseCenterUsernameRect.Add(referencePoint)
+	}
+
+	return baseUpUsernameRect.Add(referencePoint)
+}
+
+// writeRegionImage writes the part of rect inside the screenshot at imagePath.
+func (s *ScreenshotUserExtractor) writeRegionImage(screenshotMat gocv.Mat, rect image.Rectangle, imagePath string) error {
+	rect, _ = util.ClipRect(rect, image.Rect(0, 0, screenshotMat.Cols(), screenshotMat.Rows()))
+	if rect.Empty() {
+		return stacktrace.NewError("failed to write region at path %s: rect is outside the screenshot", imagePath)
+	}
+
+	regionMat := screenshotMat.Region(rect)
+	defer regionMat.Close()
+
+	writeSuccess := gocv.IMWrite(imagePath, regionMat)
+	if !writeSuccess {
+		return stacktrace.NewError("failed to write mat at path %s", imagePath)
+	}
+
+	return nil
+}
+
+// ocrUsernames reads the username inside each rect, along with the OCR result it was read from (confidences
+// and character boxes). Inferred rows may have no username at all (e.g. our own account's row), so an empty
+// OCR result gives them an empty username instead of an error. Empty rects (e.g. partial rows) are not read
+// and get an empty username. Rows without a username get an empty result.
+func (s *ScreenshotUserExtractor) ocrUsernames(ctx context.Context, screenshotMat gocv.Mat, usernameRects []image.Rectangle, inferred []bool) ([]string, []ocr.Result, error) {
+	results, err := s.readRegions(ctx, s.tocr, screenshotMat, usernameRects)
+	if err != nil {
+		return nil, nil, stacktrace.Propagate(err, "failed to read usernames")
+	}
+
+	usernames := make([]string, len(usernameRects))
+	usernameResults := make([]ocr.Result, len(usernameRects))
+	for i, usernameRect := range usernameRects {
+		if usernameRect.Empty() {
+			continue
 		}
 
-		usernameOcrTxt := string(usernameOcrTxtBytes)
-		usernameOcrTxtLines := strings.Split(usernameOcrTxt, "\n")
+		result := results[i]
+		usernameOcrTxtLines := strings.Split(result.Text, "\n")
 		usernameOcrTxtLines = util.RemoveEmptyString(usernameOcrTxtLines)
 
+		if inferred[i] && len(usernameOcrTxtLines) == 0 {
+			continue
+		}
+
 		if len(usernameOcrTxtLines) != 1 {
-			return nil, stacktrace.NewError(
-				"failed to execute ocr in image %s: number of lines returned is different than 1 (%d)",
-				usernameImagePath,
+			return nil, nil, stacktrace.NewError(
+				"failed to read username at %v: number of lines returned is different than 1 (%d)",
+				usernameRect,
 				len(usernameOcrTxtLines),
 			)
 		}
 
-		usernames = append(usernames, usernameOcrTxtLines[0])
+		usernames[i] = usernameOcrTxtLines[0]
+		usernameResults[i] = result
 	}
 
-	return usernames, nil
+	return usernames, usernameResults, nil
+}
+
+// getUsernamesToReview flags the usernames OCR is not sure about: those with a word whose confidence is below
+// UsernameReviewConfidence. Returns whether each username needs review along with a warning for each flagged one.
+// Nothing is flagged when UsernameReviewConfidence is 0.
+func (s *ScreenshotUserExtractor) getUsernamesToReview(usernames []string, results []ocr.Result, referencePoints []image.Point) ([]bool, []string) {
+	needsReview := make([]bool, len(usernames))
+	if s.config.UsernameReviewConfidence <= 0 {
+		return needsReview, nil
+	}
+
+	var warnings []string
+	for i, username := range usernames {
+		if username == "" {
+			continue
+		}
+
+		confidence := results[i].MinConfidence()
+		if confidence < s.config.UsernameReviewConfidence {
+			needsReview[i] = true
+			warnings = append(warnings, fmt.Sprintf(
+				"username %q at %v was read with confidence %.1f, below %.1f, it needs review",
+				username, referencePoints[i], confidence, s.config.UsernameReviewConfidence))
+		}
+	}
+
+	return needsReview, warnings
+}
+
+// validateUsernames checks the usernames read by OCR against the Instagram username grammar, correcting the
+// reads that break it when a known OCR confusion explains them. Truncated usernames are checked as prefixes.
+// Returns the validation of each username, where empty or unchecked usernames are kept as read, along with a
+// warning for each corrected or invalid one. Nothing is checked unless ValidateUsernames is set.
+func (s *ScreenshotUserExtractor) validateUsernames(usernames []string, usernameBoxes []usernamebox.Box, referencePoints []image.Point) ([]usernamegrammar.Validation, []string) {
+	validations := make([]usernamegrammar.Validation, len(usernames))
+	var warnings []string
+	for i, username := range usernames {
+		if username == "" || !s.config.ValidateUsernames {
+			validations[i] = usernamegrammar.Validation{Username: username, Raw: username}
+			continue
+		}
+
+		validations[i] = usernamegrammar.Validate(username, usernameBoxes[i].Truncated)
+		switch validations[i].Status {
+		case usernamegrammar.StatusCorrected:
+			warnings = append(warnings, fmt.Sprintf("username %q at %v is not valid (%s), corrected to %q",
+				username, referencePoints[i], validations[i].Problem, validations[i].Username))
+		case usernamegrammar.StatusInvalid:
+			warnings = append(warnings, fmt.Sprintf("username %q at %v is not valid (%s) and could not be corrected",
+				username, referencePoints[i], validations[i].Problem))
+		}
+	}
+
+	return validations, warnings
+}
+
+// ocrDisplayNames reads the display name line of each row and returns the rects read along with the display
+// names. Rows without a display name line get an empty display name, as do display names made only of
+// characters the OCR engine can not read (e.g. emoji). Skipped rows (partial or occluded) are not read and get
+// an empty rect, since their display name line may be another line, such as the username of a row cut
+// by the screenshot top edge.
+func (s *ScreenshotUserExtractor) ocrDisplayNames(ctx context.Context, screenshotMat gocv.Mat, layouts []rowlayout.Layout, skipped []bool) ([]image.Rectangle, []string, error) {
+	displayNameRects := make([]image.Rectangle, len(layouts))
+	for i, layout := range layouts {
+		if skipped[i] {
+			continue
+		}
+		displayNameRects[i] = layout.DisplayName
+	}
+
+	results, err := s.readRegions(ctx, s.dnocr, screenshotMat, displayNameRects)
+	if err != nil {
+		return nil, nil, stacktrace.Propagate(err, "failed to read display names")
+	}
+
+	displayNames := make([]string, len(layouts))
+	for i, result := range results {
+		displayNameOcrTxtLines := strings.Split(result.Text, "\n")
+		displayNameOcrTxtLines = util.RemoveEmptyString(displayNameOcrTxtLines)
+		displayNames[i] = strings.TrimSpace(strings.Join(displayNameOcrTxtLines, " "))
+	}
+
+	return displayNameRects, displayNames, nil
+}
+
+// readRegions reads the text inside each rect with engine, with up to OcrConcurrency reads running at the
+// same time. When BatchOcr is set and the engine supports it, the rects are read in batch calls of at least
+// OcrMinBatchSize rects, up to OcrConcurrency of them, instead of one call per rect. Results keep the order
+// of rects, so region indexes in errors are row indexes. Empty rects are not read and get an empty result.
+func (s *ScreenshotUserExtractor) readRegions(ctx context.Context, engine ocr.Engine, screenshotMat gocv.Mat, rects []image.Rectangle) ([]ocr.Result, error) {
+	var results []ocr.Result
+	var err error
+	if s.config.BatchOcr {
+		results, err = ocr.ReadAll(ctx, engine, screenshotMat, rects, s.config.OcrMinBatchSize, s.config.OcrConcurrency)
+	} else {
+		results, err = ocr.ReadEach(ctx, engine, screenshotMat, rects, s.config.OcrConcurrency)
+	}
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "failed to read %d regions", len(rects))
+	}
+
+	return results, nil
 }
@@ -1,58 +1,52 @@
 package screenshotuserextractor_test
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"image"
+	"runtime"
+	"strings"
 	"testing"
 
+	"github.com/palantir/stacktrace"
 	"github.com/rogeriofbrito/go-insta-scraper-v2/config"
+	"github.com/rogeriofbrito/go-insta-scraper-v2/ocr"
 	"github.com/rogeriofbrito/go-insta-scraper-v2/screenshotuserextractor"
-	"github.com/rogeriofbrito/go-insta-scraper-v2/templatematcher"
-	"github.com/rogeriofbrito/go-insta-scraper-v2/tesseractocr"
+	"github.com/rogeriofbrito/go-insta-scraper-v2/usernamegrammar"
 	"github.com/rogeriofbrito/go-insta-scraper-v2/util"
 	"gocv.io/x/gocv"
 )
 
 func TestScreenshotUserExtractor_GetUsernames_DiverseCases(t *testing.T) {
 	tests := []struct {
-		name                  string
-		screenshotPath        string
-		templateFollowPath    string
-		templateFollowingPath string
-		templateMessagePath   string
-		config                config.Config
-		expectedUsernames     []string
-		expectErr             bool
+		name              string
+		screenshotPath    string
+		configure         func(cfg *config.Config) // Changes to newTestConfig, if any
+		expectedUsernames []string
+		expectErr         bool
 	}{
 		{
-			name:                  "iphone_14_plus_1",
-			screenshotPath:        "testdata/iphone_14_plus_1/screenshot.png",
-			templateFollowPath:    "testdata/iphone_14_plus_1/follow.png",
-			templateFollowingPath: "testdata/iphone_14_plus_1/following.png",
-			templateMessagePath:   "testdata/iphone_14_plus_1/following.png", // TODO: change ScreenshotUserExtractor to accept omit templates
-			config: config.Config{
-				WorkingDirPath:             "/tmp/go-insta-scraper",
-				ReferencePointsSearchRect:  image.Rect(600, 308, 675, 1690),
-				ReferencePointsXCoordinate: 629,
-				GroupAveragesThreshold:     10,
-				MatchTemplateThreshold:     float32(0.8),
-				MatchTemplateMethod:        gocv.TmCcoeffNormed,
-				MatchTemplateImageFlags:    gocv.IMReadColor,
-				OcrImageFlags:              gocv.IMReadGrayScale,
-				UniformThresold:            5,
-				SamplePosition: config.SamplePosition{
-					ReferencePoint:        image.Pt(629, 501),
-					TopCenterUsernameRect: image.Rect(165, 482, 165+440, 482+36),
-					CenterUsernameRect:    image.Rect(165, 518, 165+440, 518+36),
-					UpUsernameRect:        image.Rect(165, 498, 165+440, 498+36),
-				},
-				TesseractOcrOem: 1,
-				TesseractOcrPsm: 7, //single text line
-				TesseractOcrConfigs: map[string]string{
-					"tessedit_char_whitelist":   "abcdefghijklmnopqrstuvwxyz0123456789._",
-					"classify_bln_numeric_mode": "1",
-					"load_system_dawg":          "0", // disable dictionary corrections
-					"load_freq_dawg":            "0", // disable dictionary corrections
-				},
+			name:           "iphone_14_plus_1",
+			screenshotPath: "testdata/iphone_14_plus_1/screenshot.png",
+			expectedUsernames: []string{
+				"matheusgonze1",
+				"stephencurry30",
+				"siganacaorubronegra",
+				"capixabaputo",
+				"kvraco",
+				"memoriarubronegra",
+				"naosalvo",
+				"belightstore_",
+				"fishfireideas",
+			},
+			expectErr: false,
+		},
+		{
+			name:           "iphone_14_plus_1_concurrent_ocr",
+			screenshotPath: "testdata/iphone_14_plus_1/screenshot.png",
+			configure: func(cfg *config.Config) {
+				cfg.OcrConcurrency = 4
 			},
 			expectedUsernames: []string{
 				"matheusgonze1",
@@ -67,29 +61,88 @@ func TestScreenshotUserExtractor_GetUsernames_DiverseCases(t *testing.T) {
 			},
 			expectErr: false,
 		},
+		{
+			name:           "iphone_14_plus_1_batch_ocr",
+			screenshotPath: "testdata/iphone_14_plus_1/screenshot.png",
+			configure: func(cfg *config.Config) {
+				cfg.BatchOcr = true
+				cfg.TesseractOcrBatchPsm = 6 // uniform block of text
+				cfg.TesseractOcrBatchSpacing = 16
+			},
+			expectedUsernames: []string{
+				"matheusgonze1",
+				"stephencurry30",
+				"siganacaorubronegra",
+				"capixabaputo",
+				"kvraco",
+				"memoriarubronegra",
+				"naosalvo",
+				"belightstore_",
+				"fishfireideas",
+			},
+			expectErr: false,
+		},
+		{
+			name:           "iphone_14_plus_1_row_layout_refined_username",
+			screenshotPath: "testdata/iphone_14_plus_1/screenshot.png",
+			configure: func(cfg *config.Config) {
+				cfg.SamplePosition.TextRect = image.Rect(165, 461, 165+440, 461+150)
+				cfg.RowLayoutInkThreshold = 40
+				cfg.RowLayoutMinLineHeight = 8
+				cfg.RowLayoutMaxLineGap = 3
+				cfg.RowLayoutLinePadding = 6
+				cfg.RefineUsernameRect = true
+				cfg.UsernameInkThreshold = 40
+				cfg.UsernameMinComponentArea = 4
+				cfg.UsernameMaxCharGap = 5
+				cfg.UsernameTruncationEdgeMargin = 3
+				cfg.UsernameRectPadding = 8
+				cfg.BadgeMinSaturation = 100
+			},
+			expectedUsernames: []string{
+				"matheusgonze1",
+				"stephencurry30",
+				"siganacaorubronegra",
+				"capixabaputo",
+				"kvraco",
+				"memoriarubronegra",
+				"naosalvo",
+				"belightstore_",
+				"fishfireideas",
+			},
+			expectErr: false,
+		},
+		{
+			// The screenshot starts right above the first button, so the first row is partial and skipped
+			name:           "iphone_14_plus_1_cut_top",
+			screenshotPath: "testdata/iphone_14_plus_1_cut_top/screenshot.png",
+			configure: func(cfg *config.Config) {
+				cfg.ReferencePointsSearchRect = image.Rect(600, 0, 675, 1305)
+			},
+			expectedUsernames: []string{
+				"stephencurry30",
+				"siganacaorubronegra",
+				"capixabaputo",
+				"kvraco",
+				"memoriarubronegra",
+				"naosalvo",
+				"belightstore_",
+				"fishfireideas",
+			},
+			expectErr: false,
+		},
 	}
 
 	for _, tc := range tests {
 		t.Run(tc.name, func(t *testing.T) {
-			err := util.CreateWorkingDir(tc.config.WorkingDirPath)
-			if err != nil {
-				t.Fatalf("error on creating working dir: %v", err)
+			cfg := newTestConfig()
+			if tc.configure != nil {
+				tc.configure(&cfg)
 			}
 
-			tm := templatematcher.NewTemplateMatcher(&tc.config)
-			tocr := tesseractocr.NewTesseractOcr(&tc.config)
+			extractor := newTestExtractor(t, tc.screenshotPath, &cfg)
 
-			extractor := screenshotuserextractor.NewScreenshotUserExtractor(
-				tc.screenshotPath,
-				tc.templateFollowPath,
-				tc.templateFollowingPath,
-				tc.templateMessagePath,
-				&tc.config,
-				tm,
-				tocr,
-			)
-
-			usernames, err := extractor.GetUsernames()
+			usernames, err := extractor.GetUsernames(context.Background())
 			if tc.expectErr {
 				if err == nil {
 					t.Fatalf("expected error but got nil")
@@ -106,8 +159,375 @@ func TestScreenshotUserExtractor_GetUsernames_DiverseCases(t *testing.T) {
 	}
 }
 
+func TestScreenshotUserExtractor_Extract_FakeOcr(t *testing.T) {
+	cfg := newTestConfig()
+
+	tests := []struct {
+		name                string
+		results             []ocr.Result
+		reviewConfidence    float64
+		validateUsernames   bool
+		expectedUsernames   []string
+		expectedConfidences []float64
+		expectedNeedsReview []bool
+		expectedStatuses    []usernamegrammar.Status // Unchecked for every row when nil
+		expectedRaw         []string                 // Same as expectedUsernames when nil
+		expectErr           bool
+	}{
+		{
+			name: "usernames_and_confidences_taken_from_engine",
+			results: []ocr.Result{
+				{Text: "user0", Confidence: 90},
+				{Text: "user1", Confidence: 91},
+				{Text: "user2", Confidence: 92},
+				{Text: "user3", Confidence: 93},
+				{Text: "user4", Confidence: 94},
+				{Text: "user5", Confidence: 95},
+				{Text: "user6", Confidence: 96},
+				{Text: "user7", Confidence: 97},
+				{Text: "user8", Confidence: 98},
+			},
+			expectedUsernames:   []string{"user0", "user1", "user2", "user3", "user4", "user5", "user6", "user7", "user8"},
+			expectedConfidences: []float64{90, 91, 92, 93, 94, 95, 96, 97, 98},
+			expectedNeedsReview: make([]bool, 9),
+		},
+		{
+			name: "usernames_below_review_confidence_flagged",
+			results: []ocr.Result{
+				{Text: "user0", Confidence: 90, Words: []ocr.Word{{Text: "user0", Confidence: 90}}},
+				{Text: "user1", Confidence: 41, Words: []ocr.Word{{Text: "user1", Confidence: 41}}},
+				{Text: "user2", Confidence: 92, Words: []ocr.Word{{Text: "user2", Confidence: 92}}},
+				{Text: "user3", Confidence: 93, Words: []ocr.Word{{Text: "user3", Confidence: 93}}},
+				{Text: "user4", Confidence: 94, Words: []ocr.Word{{Text: "user4", Confidence: 94}}},
+				{Text: "user5", Confidence: 95, Words: []ocr.Word{{Text: "user5", Confidence: 95}}},
+				{Text: "user6", Confidence: 69.9, Words: []ocr.Word{{Text: "user6", Confidence: 69.9}}},
+				{Text: "user7", Confidence: 97, Words: []ocr.Word{{Text: "user7", Confidence: 97}}},
+				{Text: "user8", Confidence: 98, Words: []ocr.Word{{Text: "user8", Confidence: 98}}},
+			},
+			reviewConfidence:    70,
+			expectedUsernames:   []string{"user0", "user1", "user2", "user3", "user4", "user5", "user6", "user7", "user8"},
+			expectedConfidences: []float64{90, 41, 92, 93, 94, 95, 69.9, 97, 98},
+			expectedNeedsReview: []bool{false, true, false, false, false, false, true, false, false},
+		},
+		{
+			name: "usernames_breaking_the_grammar_corrected_or_invalid",
+			results: []ocr.Result{
+				{Text: "user0", Confidence: 90},
+				{Text: "user1.", Confidence: 91},
+				{Text: "user..2", Confidence: 92},
+				{Text: ".user3.", Confidence: 93},
+				{Text: "user4", Confidence: 94},
+				{Text: "...", Confidence: 95},
+				{Text: "user6", Confidence: 96},
+				{Text: "user7", Confidence: 97},
+				{Text: "user8", Confidence: 98},
+			},
+			validateUsernames:   true,
+			expectedUsernames:   []string{"user0", "user1", "user.2", "user3", "user4", "", "user6", "user7", "user8"},
+			expectedConfidences: []float64{90, 91, 92, 93, 94, 95, 96, 97, 98},
+			expectedNeedsReview: make([]bool, 9),
+			expectedStatuses: []usernamegrammar.Status{
+				usernamegrammar.StatusValid,
+				usernamegrammar.StatusCorrected,
+				usernamegrammar.StatusCorrected,
+				usernamegrammar.StatusCorrected,
+				usernamegrammar.StatusValid,
+				usernamegrammar.StatusInvalid,
+				usernamegrammar.StatusValid,
+				usernamegrammar.StatusValid,
+				usernamegrammar.StatusValid,
+			},
+			expectedRaw: []string{"user0", "user1.", "user..2", ".user3.", "user4", "...", "user6", "user7", "user8"},
+		},
+		{
+			name: "more_than_one_line_read",
+			results: []ocr.Result{
+				{Text: "user0\nuser1", Confidence: 90},
+			},
+			expectErr: true,
+		},
+		{
+			name: "empty_result_for_a_row_that_was_found",
+			results: []ocr.Result{
+				{Text: "user0", Confidence: 90},
+				{},
+			},
+			expectErr: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := cfg
+			cfg.UsernameReviewConfidence = tc.reviewConfidence
+			cfg.ValidateUsernames = tc.validateUsernames
+
+			tocr := ocr.NewFakeEngine(tc.results...)
+			dnocr := ocr.NewFakeEngine()
+
+			extractor := newTestExtractor(t, "testdata/iphone_14_plus_1/screenshot.png", &cfg, withOcr(tocr, dnocr))
+
+			result, err := extractor.Extract(context.Background())
+			if tc.expectErr {
+				if err == nil {
+					t.Fatalf("expected error but got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(result.Rows) != len(tc.expectedUsernames) {
+				t.Fatalf("Extract() returned %d rows; expected %d", len(result.Rows), len(tc.expectedUsernames))
+			}
+			for i, row := range result.Rows {
+				if row.Username != tc.expectedUsernames[i] || row.UsernameConfidence != tc.expectedConfidences[i] {
+					t.Errorf("row %d = (%q, %v); expected (%q, %v)",
+						i, row.Username, row.UsernameConfidence, tc.expectedUsernames[i], tc.expectedConfidences[i])
+				}
+				if row.NeedsReview != tc.expectedNeedsReview[i] {
+					t.Errorf("row %d NeedsReview = %v; expected %v", i, row.NeedsReview, tc.expectedNeedsReview[i])
+				}
+				expectedStatus := usernamegrammar.StatusUnchecked
+				if tc.expectedStatuses != nil {
+					expectedStatus = tc.expectedStatuses[i]
+				}
+				if row.UsernameStatus != expectedStatus {
+					t.Errorf("row %d UsernameStatus = %v; expected %v", i, row.UsernameStatus, expectedStatus)
+				}
+				expectedRaw := tc.expectedUsernames[i]
+				if tc.expectedRaw != nil {
+					expectedRaw = tc.expectedRaw[i]
+				}
+				if row.UsernameRaw != expectedRaw {
+					t.Errorf("row %d UsernameRaw = %q; expected %q", i, row.UsernameRaw, expectedRaw)
+				}
+				if tocr.Rects()[i] != row.UsernameRect {
+					t.Errorf("row %d read at %v; expected %v", i, tocr.Rects()[i], row.UsernameRect)
+				}
+			}
+			if len(dnocr.Rects()) != 0 {
+				t.Errorf("display names read at %v; expected none without a text rect", dnocr.Rects())
+			}
+		})
+	}
+}
+
+func TestScreenshotUserExtractor_Extract_CanceledContext(t *testing.T) {
+	cfg := newTestConfig()
+
+	tocr := ocr.NewFakeEngine()
+	extractor := newTestExtractor(t, "testdata/iphone_14_plus_1/screenshot.png", &cfg, withOcr(tocr, ocr.NewFakeEngine()))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := extractor.Extract(ctx)
+	if err == nil {
+		t.Fatalf("expected error but got nil")
+	}
+	if !errors.Is(stacktrace.RootCause(err), context.Canceled) {
+		t.Fatalf("Extract() error = %v; expected it to be caused by context.Canceled", err)
+	}
+	if len(tocr.Rects()) != 0 {
+		t.Errorf("usernames read at %v; expected no OCR once the context is done", tocr.Rects())
+	}
+}
+
+func TestScreenshotUserExtractor_Extract_PartialRowDisplayName(t *testing.T) {
+	cfg := newTestConfig()
+	cfg.ReferencePointsSearchRect = image.Rect(600, 0, 675, 1305)
+	cfg.SamplePosition.TextRect = image.Rect(165, 461, 165+440, 461+150)
+	cfg.RowLayoutInkThreshold = 40
+	cfg.RowLayoutMinLineHeight = 8
+	cfg.RowLayoutMaxLineGap = 3
+	cfg.RowLayoutLinePadding = 6
+
+	var usernameResults, displayNameResults []ocr.Result
+	for i := range 20 {
+		usernameResults = append(usernameResults, ocr.Result{Text: fmt.Sprintf("user%d", i), Confidence: 90})
+		displayNameResults = append(displayNameResults, ocr.Result{Text: fmt.Sprintf("User %d", i), Confidence: 90})
+	}
+	dnocr := ocr.NewFakeEngine(displayNameResults...)
+
+	extractor := newTestExtractor(t, "testdata/iphone_14_plus_1_cut_top/screenshot.png", &cfg, withOcr(ocr.NewFakeEngine(usernameResults...), dnocr))
+
+	// The first row is cut by the screenshot top edge, so its display name line may be another line
+	result, err := extractor.Extract(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	partialRows := 0
+	for i, row := range result.Rows {
+		if !row.Partial {
+			continue
+		}
+		partialRows++
+		if !row.DisplayNameRect.Empty() || row.DisplayName != "" {
+			t.Errorf("partial row %d display name = (%v, %q); expected it not to be read", i, row.DisplayNameRect, row.DisplayName)
+		}
+		for _, rect := range dnocr.Rects() {
+			if rect.Overlaps(row.UsernameRect) {
+				t.Errorf("display name read at %v, over the partial row %d username rect %v", rect, i, row.UsernameRect)
+			}
+		}
+	}
+	if partialRows == 0 {
+		t.Fatalf("Extract() returned no partial row; expected the row cut by the top edge")
+	}
+}
+
+func TestScreenshotUserExtractor_Extract_GrayscaleRowAttributes(t *testing.T) {
+	cfg := newTestConfig()
+	cfg.MatchTemplateImageFlags = gocv.IMReadGrayScale
+	cfg.SamplePosition.AvatarRect = image.Rect(25, 469, 25+132, 469+132)
+	cfg.DetectRowAttributes = true
+	cfg.BadgeSearchWidth = 50
+	cfg.BadgeMinSaturation = 100
+	cfg.BadgeMinPixels = 100
+	cfg.StoryRingWidth = 8
+
+	// More results than rows, so every row found gets a username whatever the grayscale matches are
+	var results []ocr.Result
+	for i := range 20 {
+		results = append(results, ocr.Result{Text: fmt.Sprintf("user%d", i), Confidence: 90})
+	}
+
+	extractor := newTestExtractor(t, "testdata/iphone_14_plus_1/screenshot.png", &cfg, withOcr(ocr.NewFakeEngine(results...), ocr.NewFakeEngine()))
+
+	// Row attributes need colors, a grayscale screenshot skips them instead of failing the extraction
+	result, err := extractor.Extract(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	warned := false
+	for _, warning := range result.Warnings {
+		if strings.HasPrefix(warning, "row attributes not detected") {
+			warned = true
+		}
+	}
+	if !warned {
+		t.Errorf("Extract() warnings = %v; expected a warning about row attributes not being detected", result.Warnings)
+	}
+	for i, row := range result.Rows {
+		if row.Verified || row.StoryRing {
+			t.Errorf("row %d Verified = %v, StoryRing = %v; expected no attributes", i, row.Verified, row.StoryRing)
+		}
+	}
+}
+
+// BenchmarkScreenshotUserExtractor_GetUsernames compares reading the usernames of a screenshot with one
+// Tesseract process per row, one at a time or concurrently, against batch calls over all rows. The
+// *_defaults cases use the OCR settings of main: one batch per screenshot, against one process per row on
+// every processor.
+func BenchmarkScreenshotUserExtractor_GetUsernames(b *testing.B) {
+	benchmarks := []struct {
+		name         string
+		batchOcr     bool
+		minBatchSize int
+		concurrency  int
+	}{
+		{name: "per_row", batchOcr: false, concurrency: 1},
+		{name: "per_row_concurrent", batchOcr: false, concurrency: 8},
+		{name: "per_row_concurrent_defaults", batchOcr: false, concurrency: runtime.NumCPU()},
+		{name: "batch", batchOcr: true, concurrency: 1},
+		{name: "batch_concurrent", batchOcr: true, concurrency: 4},
+		{name: "batch_defaults", batchOcr: true, minBatchSize: 16, concurrency: 1},
+		{name: "batch_concurrent_with_min_batch_size", batchOcr: true, minBatchSize: 16, concurrency: runtime.NumCPU()},
+	}
+
+	for _, bm := range benchmarks {
+		b.Run(bm.name, func(b *testing.B) {
+			cfg := newTestConfig()
+			cfg.BatchOcr = bm.batchOcr
+			cfg.TesseractOcrBatchPsm = 6 // uniform block of text
+			cfg.TesseractOcrBatchSpacing = 16
+			cfg.OcrMinBatchSize = bm.minBatchSize
+			cfg.OcrConcurrency = bm.concurrency
+
+			extractor := newTestExtractor(b, "testdata/iphone_14_plus_1/screenshot.png", &cfg)
+
+			for b.Loop() {
+				_, err := extractor.GetUsernames(context.Background())
+				if err != nil {
+					b.Fatalf("unexpected error: %v", err)
+				}
+			}
+		})
+	}
+}
+
 // --- helpers ---
 
+// newTestConfig returns the config of the iphone_14_plus_1 screenshots, reading usernames with Tesseract.
+func newTestConfig() config.Config {
+	return config.Config{
+		WorkingDirPath:             "/tmp/go-insta-scraper",
+		ReferencePointsSearchRect:  image.Rect(600, 308, 675, 1690),
+		ReferencePointsXCoordinate: 629,
+		GroupAveragesThreshold:     10,
+		MatchTemplateThreshold:     float32(0.8),
+		MatchTemplateMethod:        gocv.TmCcoeffNormed,
+		MatchTemplateImageFlags:    gocv.IMReadColor,
+		OcrImageFlags:              gocv.IMReadGrayScale,
+		UniformThresold:            5,
+		SamplePosition: config.SamplePosition{
+			ReferencePoint:        image.Pt(629, 501),
+			TopCenterUsernameRect: image.Rect(165, 482, 165+440, 482+36),
+			CenterUsernameRect:    image.Rect(165, 518, 165+440, 518+36),
+			UpUsernameRect:        image.Rect(165, 498, 165+440, 498+36),
+		},
+		TesseractOcrOem: 1,
+		TesseractOcrPsm: 7, //single text line
+		TesseractOcrConfigs: map[string]string{
+			"tessedit_char_whitelist":   "abcdefghijklmnopqrstuvwxyz0123456789._",
+			"classify_bln_numeric_mode": "1",
+			"load_system_dawg":          "0", // disable dictionary corrections
+			"load_freq_dawg":            "0", // disable dictionary corrections
+		},
+	}
+}
+
+// newTestExtractor returns an extractor of the screenshot at screenshotPath matched against the
+// iphone_14_plus_1 templates, with the collaborators of main changed by overrides.
+func newTestExtractor(
+	tb testing.TB,
+	screenshotPath string,
+	cfg *config.Config,
+	overrides ...func(deps *screenshotuserextractor.Dependencies),
+) *screenshotuserextractor.ScreenshotUserExtractor {
+	tb.Helper()
+
+	err := util.CreateWorkingDir(cfg.WorkingDirPath)
+	if err != nil {
+		tb.Fatalf("error on creating working dir: %v", err)
+	}
+
+	deps := screenshotuserextractor.NewDependencies(cfg)
+	for _, override := range overrides {
+		override(&deps)
+	}
+
+	return screenshotuserextractor.NewScreenshotUserExtractor(
+		screenshotPath,
+		"testdata/iphone_14_plus_1/follow.png",
+		"testdata/iphone_14_plus_1/following.png",
+		"testdata/iphone_14_plus_1/following.png", // TODO: change ScreenshotUserExtractor to accept omit templates
+		cfg,
+		deps,
+	)
+}
+
+// withOcr replaces the engines reading usernames and display names, e.g. with fakes.
+func withOcr(usernameOcr, displayNameOcr ocr.Engine) func(deps *screenshotuserextractor.Dependencies) {
+	return func(deps *screenshotuserextractor.Dependencies) {
+		deps.UsernameOcr = usernameOcr
+		deps.DisplayNameOcr = displayNameOcr
+	}
+}
+
 func stringSliceEqual(a, b []string) bool {
 	if a == nil && b == nil {
 		return true
@@ -0,0 +1,165 @@
+package sectionheader
+
+import (
+	"context"
+	"image"
+	"slices"
+
+	"github.com/palantir/stacktrace"
+	"github.com/rogeriofbrito/go-insta-scraper-v2/config"
+	"github.com/rogeriofbrito/go-insta-scraper-v2/tesseractocr"
+	"github.com/rogeriofbrito/go-insta-scraper-v2/util"
+	"gocv.io/x/gocv"
+)
+
+// Header is a section header found in a list screenshot.
+type Header struct {
+	Phrase string          // Configured phrase that matched the header text
+	Rect   image.Rectangle // Bounding box of the header text in the screenshot
+}
+
+// minInkPerLine is the minimum number of ink pixels for a pixel row to be part of a text line.
+const minInkPerLine = 1
+
+// NewSectionHeaderDetector creates a new SectionHeaderDetector. Text lines are found with the RowLayout*
+// line detection parameters of config, and tocr is used to read each of them, so it should read a single
+// line without restricting the characters it recognizes (see tesseractocr.NewSectionHeaderTesseractOcr).
+func NewSectionHeaderDetector(config *config.Config, tocr *tesseractocr.TesseractOcr) *SectionHeaderDetector {
+	return &SectionHeaderDetector{
+		config: config,
+		tocr:   tocr,
+	}
+}
+
+// SectionHeaderDetector finds the "Suggested for you" header Instagram shows after the last account of
+// a list, above accounts that are not part of it.
+type SectionHeaderDetector struct {
+	config *config.Config
+	tocr   *tesseractocr.TesseractOcr
+}
+
+// Find returns the topmost header matching one of SuggestionsHeaderPhrases in the SuggestionsHeaderSearchRect
+// area of the screenshot. rowRects are the rows found in the screenshot: a header is never part of a row, so
+// only the text lines between rows are read, one at a time and from top to bottom, which keeps OCR off the
+// list. Returns false if there is no header.
+func (d *SectionHeaderDetector) Find(ctx context.Context, screenshotMat gocv.Mat, rowRects []image.Rectangle) (Header, bool, error) {
+	lines, err := d.FindLines(screenshotMat, rowRects)
+	if err != nil {
+		return Header{}, false, stacktrace.Propagate(err, "failed to find text lines between rows")
+	}
+
+	bounds := image.Rect(0, 0, screenshotMat.Cols(), screenshotMat.Rows())
+	for _, line := range lines {
+		lineRect, _ := util.ClipRect(line.Inset(-d.config.RowLayoutLinePadding), bounds)
+		words, err := d.tocr.ReadWords(ctx, screenshotMat, lineRect)
+		if err != nil {
+			return Header{}, false, stacktrace.Propagate(err, "failed to execute tesseract ocr over line %v", lineRect)
+		}
+
+		if header, found := FindHeader(words, d.config.SuggestionsHeaderPhrases); found {
+			return header, true, nil
+		}
+	}
+
+	return Header{}, false, nil
+}
+
+// FindLines returns the bounding boxes of the text lines of the SuggestionsHeaderSearchRect area of the
+// screenshot that are outside every row of rowRects, from top to bottom. Lines are found with projection
+// profiles over the gaps between rows, each gap with its own background level.
+func (d *SectionHeaderDetector) FindLines(screenshotMat gocv.Mat, rowRects []image.Rectangle) ([]image.Rectangle, error) {
+	searchRect, _ := util.ClipRect(d.config.SuggestionsHeaderSearchRect, image.Rect(0, 0, screenshotMat.Cols(), screenshotMat.Rows()))
+	if searchRect.Empty() {
+		return nil, nil
+	}
+
+	var lines []image.Rectangle
+	for _, gapRect := range getGapRects(searchRect, rowRects) {
+		if gapRect.Dy() < d.config.RowLayoutMinLineHeight {
+			continue
+		}
+
+		gapLines, err := d.getLines(screenshotMat, gapRect)
+		if err != nil {
+			return nil, stacktrace.Propagate(err, "failed to get text lines of %v", gapRect)
+		}
+		lines = append(lines, gapLines...)
+	}
+
+	return lines, nil
+}
+
+func (d *SectionHeaderDetector) getLines(screenshotMat gocv.Mat, gapRect image.Rectangle) ([]image.Rectangle, error) {
+	background, err := util.GetBackgroundLevel(screenshotMat, gapRect)
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "failed to get background level")
+	}
+
+	rowsProfile, _, err := util.GetProjectionProfiles(screenshotMat, gapRect, background, d.config.RowLayoutInkThreshold)
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "failed to get projection profiles")
+	}
+
+	var lines []image.Rectangle
+	runs := util.FindRuns(rowsProfile, minInkPerLine, d.config.RowLayoutMaxLineGap, d.config.RowLayoutMinLineHeight)
+	for _, run := range runs {
+		bandRect := image.Rect(gapRect.Min.X, gapRect.Min.Y+run.Start, gapRect.Max.X, gapRect.Min.Y+run.End)
+		_, colsProfile, err := util.GetProjectionProfiles(screenshotMat, bandRect, background, d.config.RowLayoutInkThreshold)
+		if err != nil {
+			return nil, stacktrace.Propagate(err, "failed to get projection profiles of line %v", bandRect)
+		}
+
+		// The header text and its "See all" link are far apart, so the whole band width is allowed as a gap
+		colRuns := util.FindRuns(colsProfile, minInkPerLine, len(colsProfile), 1)
+		if len(colRuns) == 0 {
+			continue
+		}
+
+		lines = append(lines, image.Rect(
+			bandRect.Min.X+colRuns[0].Start, bandRect.Min.Y,
+			bandRect.Min.X+colRuns[len(colRuns)-1].End, bandRect.Max.Y,
+		))
+	}
+
+	return lines, nil
+}
+
+// getGapRects returns the full-width bands of searchRect that no row of rowRects covers, from top to bottom.
+func getGapRects(searchRect image.Rectangle, rowRects []image.Rectangle) []image.Rectangle {
+	sortedRowRects := slices.Clone(rowRects)
+	slices.SortFunc(sortedRowRects, func(a, b image.Rectangle) int {
+		return a.Min.Y - b.Min.Y
+	})
+
+	var gapRects []image.Rectangle
+	top := searchRect.Min.Y
+	for _, rowRect := range sortedRowRects {
+		if rowRect.Empty() {
+			continue
+		}
+		if rowRect.Min.Y > top {
+			gapRects = append(gapRects, image.Rect(searchRect.Min.X, top, searchRect.Max.X, min(rowRect.Min.Y, searchRect.Max.Y)))
+		}
+		top = max(top, rowRect.Max.Y)
+		if top >= searchRect.Max.Y {
+			return gapRects
+		}
+	}
+
+	return append(gapRects, image.Rect(searchRect.Min.X, top, searchRect.Max.X, searchRect.Max.Y))
+}
+
+// FindHeader returns the topmost occurrence of any of phrases among the recognized words.
+func FindHeader(words []tesseractocr.Word, phrases []string) (Header, bool) {
+	var header Header
+	found := false
+	for _, phrase := range phrases {
+		rect, phraseFound := tesseractocr.FindPhrase(words, phrase)
+		if phraseFound && (!found || rect.Min.Y < header.Rect.Min.Y) {
+			header = Header{Phrase: phrase, Rect: rect}
+			found = true
+		}
+	}
+
+	return header, found
+}
@@ -0,0 +1,166 @@
+package sectionheader_test
+
+import (
+	"image"
+	"image/color"
+	"os"
+	"slices"
+	"testing"
+
+	"github.com/rogeriofbrito/go-insta-scraper-v2/config"
+	"github.com/rogeriofbrito/go-insta-scraper-v2/sectionheader"
+	"github.com/rogeriofbrito/go-insta-scraper-v2/tesseractocr"
+	"gocv.io/x/gocv"
+)
+
+func TestFindHeader_DiverseCases(t *testing.T) {
+	// Words of the list area of the last screenshot of a list: a row, the header, a suggested row whose
+	// subtitle repeats the header text
+	hocrFile, err := os.Open("testdata/list_end.hocr")
+	if err != nil {
+		t.Fatalf("failed to open hocr: %v", err)
+	}
+	defer hocrFile.Close()
+
+	words, err := tesseractocr.ParseHocr(hocrFile)
+	if err != nil {
+		t.Fatalf("failed to parse hocr: %v", err)
+	}
+
+	tests := []struct {
+		name           string
+		phrases        []string
+		expectedHeader sectionheader.Header
+		expectedFound  bool
+	}{
+		{
+			name:           "phrase_spelled_as_read_by_ocr",
+			phrases:        []string{"Sugestoes para voce", "Sugestoes para você"},
+			expectedHeader: sectionheader.Header{Phrase: "Sugestoes para você", Rect: image.Rect(32, 300, 430, 334)},
+			expectedFound:  true,
+		},
+		{
+			name:           "topmost_occurrence_wins",
+			phrases:        []string{"Sugestões para você", "Sugestoes para você"},
+			expectedHeader: sectionheader.Header{Phrase: "Sugestoes para você", Rect: image.Rect(32, 300, 430, 334)},
+			expectedFound:  true,
+		},
+		{
+			name:          "other_language_not_found",
+			phrases:       []string{"Suggested for you"},
+			expectedFound: false,
+		},
+		{
+			name:          "no_phrases",
+			phrases:       nil,
+			expectedFound: false,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			header, found := sectionheader.FindHeader(words, tc.phrases)
+			if found != tc.expectedFound {
+				t.Fatalf("FindHeader(%q) found = %v; expected %v", tc.phrases, found, tc.expectedFound)
+			}
+			if found && header != tc.expectedHeader {
+				t.Errorf("FindHeader(%q) = %+v; expected %+v", tc.phrases, header, tc.expectedHeader)
+			}
+		})
+	}
+}
+
+// newListMat returns a light BGR list area with a filled bar of dark ink standing for each text of texts.
+func newListMat(size image.Point, texts []image.Rectangle) gocv.Mat {
+	mat := gocv.NewMatWithSizeFromScalar(gocv.NewScalar(250, 250, 250, 0), size.Y, size.X, gocv.MatTypeCV8UC3)
+	for _, text := range texts {
+		gocv.Rectangle(&mat, text, color.RGBA{R: 20, G: 20, B: 20, A: 255}, -1)
+	}
+	return mat
+}
+
+func TestSectionHeaderDetector_FindLines_DiverseCases(t *testing.T) {
+	// A row, the header with its "See all" link, a row and a line right below its text
+	texts := []image.Rectangle{
+		image.Rect(60, 30, 200, 45),
+		image.Rect(10, 80, 150, 95),
+		image.Rect(240, 82, 290, 93),
+		image.Rect(60, 140, 200, 150),
+		image.Rect(10, 152, 100, 165),
+	}
+	rowRects := []image.Rectangle{
+		image.Rect(0, 20, 300, 60),
+		image.Rect(0, 110, 300, 150),
+	}
+
+	tests := []struct {
+		name          string
+		searchRect    image.Rectangle
+		rowRects      []image.Rectangle
+		expectedLines []image.Rectangle
+	}{
+		{
+			name:       "only_lines_between_rows",
+			searchRect: image.Rect(0, 0, 300, 200),
+			rowRects:   rowRects,
+			expectedLines: []image.Rectangle{
+				image.Rect(10, 80, 290, 95),
+				image.Rect(10, 152, 100, 165),
+			},
+		},
+		{
+			name:       "rows_in_any_order",
+			searchRect: image.Rect(0, 0, 300, 200),
+			rowRects:   []image.Rectangle{rowRects[1], rowRects[0]},
+			expectedLines: []image.Rectangle{
+				image.Rect(10, 80, 290, 95),
+				image.Rect(10, 152, 100, 165),
+			},
+		},
+		{
+			name:       "without_rows_close_lines_merge",
+			searchRect: image.Rect(0, 0, 300, 200),
+			rowRects:   nil,
+			expectedLines: []image.Rectangle{
+				image.Rect(60, 30, 200, 45),
+				image.Rect(10, 80, 290, 95),
+				image.Rect(10, 140, 200, 165),
+			},
+		},
+		{
+			name:          "rows_cover_the_search_rect",
+			searchRect:    image.Rect(0, 0, 300, 200),
+			rowRects:      []image.Rectangle{image.Rect(0, 0, 300, 120), image.Rect(0, 100, 300, 200)},
+			expectedLines: nil,
+		},
+		{
+			name:          "search_rect_outside_the_screenshot",
+			searchRect:    image.Rect(400, 0, 500, 200),
+			rowRects:      rowRects,
+			expectedLines: nil,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			screenshotMat := newListMat(image.Pt(300, 200), texts)
+			defer screenshotMat.Close()
+
+			cfg := config.Config{
+				SuggestionsHeaderSearchRect: tc.searchRect,
+				RowLayoutInkThreshold:       40,
+				RowLayoutMinLineHeight:      8,
+				RowLayoutMaxLineGap:         3,
+			}
+			d := sectionheader.NewSectionHeaderDetector(&cfg, nil)
+
+			lines, err := d.FindLines(screenshotMat, tc.rowRects)
+			if err != nil {
+				t.Fatalf("FindLines() unexpected error: %v", err)
+			}
+			if !slices.Equal(lines, tc.expectedLines) {
+				t.Errorf("FindLines(%v) = %v; expected %v", tc.rowRects, lines, tc.expectedLines)
+			}
+		})
+	}
+}
@@ -0,0 +1,242 @@
+package spinnerdetector
+
+import (
+	"image"
+	"math"
+	"sort"
+
+	"github.com/palantir/stacktrace"
+	"github.com/rogeriofbrito/go-insta-scraper-v2/config"
+	"github.com/rogeriofbrito/go-insta-scraper-v2/util"
+	"gocv.io/x/gocv"
+)
+
+// minSpokeArea is the minimum number of pixels of a connected component for it to be a spinner spoke,
+// smaller ones are noise.
+const minSpokeArea = 4
+
+// NewSpinnerDetector creates a new SpinnerDetector with the spinner search parameters of config.
+func NewSpinnerDetector(config *config.Config) *SpinnerDetector {
+	return &SpinnerDetector{
+		config: config,
+	}
+}
+
+// SpinnerDetector finds the loading spinner shown below the last row while more accounts are loaded.
+// The spinner is a ring of short spokes of fading gray, which turns, so it is found by its geometry
+// instead of a template: small components lying on a circle.
+type SpinnerDetector struct {
+	config *config.Config
+}
+
+// Find returns the bounding box of the loading spinner inside SpinnerSearchRect of the screenshot, and
+// whether it was found. Pixels whose gray value differs from the background (the most frequent gray value
+// of the search area) by more than SpinnerInkThreshold are split into connected components, and
+// components no longer than SpinnerMaxSpokeLength are spoke candidates (see FindSpinner).
+func (d *SpinnerDetector) Find(imageMat gocv.Mat) (image.Rectangle, bool, error) {
+	searchRect, _ := util.ClipRect(d.config.SpinnerSearchRect, image.Rect(0, 0, imageMat.Cols(), imageMat.Rows()))
+	if searchRect.Empty() {
+		return image.Rectangle{}, false, nil
+	}
+
+	inkMat, err := d.getInkMask(imageMat, searchRect)
+	if err != nil {
+		return image.Rectangle{}, false, stacktrace.Propagate(err, "failed to get ink mask")
+	}
+	defer inkMat.Close()
+
+	labelsMat := gocv.NewMat()
+	defer labelsMat.Close()
+	statsMat := gocv.NewMat()
+	defer statsMat.Close()
+	centroidsMat := gocv.NewMat()
+	defer centroidsMat.Close()
+
+	count := gocv.ConnectedComponentsWithStats(inkMat, &labelsMat, &statsMat, &centroidsMat)
+
+	var spokes []image.Rectangle
+	// Label 0 is the background
+	for label := 1; label < count; label++ {
+		left := int(statsMat.GetIntAt(label, int(gocv.CC_STAT_LEFT)))
+		top := int(statsMat.GetIntAt(label, int(gocv.CC_STAT_TOP)))
+		width := int(statsMat.GetIntAt(label, int(gocv.CC_STAT_WIDTH)))
+		height := int(statsMat.GetIntAt(label, int(gocv.CC_STAT_HEIGHT)))
+		area := int(statsMat.GetIntAt(label, int(gocv.CC_STAT_AREA)))
+
+		if area < minSpokeArea || max(width, height) > d.config.SpinnerMaxSpokeLength {
+			continue
+		}
+
+		spokes = append(spokes, image.Rect(left, top, left+width, top+height).Add(searchRect.Min))
+	}
+
+	spinnerRect, found := FindSpinner(spokes, d.config)
+
+	return spinnerRect, found, nil
+}
+
+// FindSpinner returns the bounding box of the first group of at least SpinnerMinSpokes spoke candidates
+// lying on a circle of radius between SpinnerMinRadius and SpinnerMaxRadius. Candidates closer than a
+// spinner diameter are grouped together, and a circle is fitted to the centers of each group; every center
+// must be within SpinnerRadiusTolerance (a fraction of the radius) of the circle. Spokes faint enough to be
+// missed do not prevent the fit, while characters of a text line, which lie on a line, fit no such circle.
+func FindSpinner(spokes []image.Rectangle, config *config.Config) (image.Rectangle, bool) {
+	for _, group := range groupSpokes(spokes, 2*config.SpinnerMaxRadius) {
+		if len(group) < config.SpinnerMinSpokes {
+			continue
+		}
+
+		var centers []point
+		for _, spoke := range group {
+			centers = append(centers, point{
+				x: float64(spoke.Min.X+spoke.Max.X) / 2,
+				y: float64(spoke.Min.Y+spoke.Max.Y) / 2,
+			})
+		}
+
+		center, radius, fitted := fitCircle(centers)
+		if !fitted || radius < float64(config.SpinnerMinRadius) || radius > float64(config.SpinnerMaxRadius) {
+			continue
+		}
+
+		onCircle := true
+		for _, c := range centers {
+			if math.Abs(math.Hypot(c.x-center.x, c.y-center.y)-radius) > config.SpinnerRadiusTolerance*radius {
+				onCircle = false
+				break
+			}
+		}
+		if !onCircle {
+			continue
+		}
+
+		spinnerRect := group[0]
+		for _, spoke := range group[1:] {
+			spinnerRect = spinnerRect.Union(spoke)
+		}
+		return spinnerRect, true
+	}
+
+	return image.Rectangle{}, false
+}
+
+type point struct {
+	x, y float64
+}
+
+// groupSpokes groups spokes whose bounding boxes are less than maxDistance apart, transitively.
+// Groups are sorted from top to bottom.
+func groupSpokes(spokes []image.Rectangle, maxDistance int) [][]image.Rectangle {
+	parents := make([]int, len(spokes))
+	for i := range parents {
+		parents[i] = i
+	}
+	var find func(i int) int
+	find = func(i int) int {
+		if parents[i] != i {
+			parents[i] = find(parents[i])
+		}
+		return parents[i]
+	}
+
+	for i := range spokes {
+		for j := i + 1; j < len(spokes); j++ {
+			if spokes[i].Inset(-maxDistance / 2).Overlaps(spokes[j].Inset(-maxDistance / 2)) {
+				parents[find(i)] = find(j)
+			}
+		}
+	}
+
+	groupsByRoot := map[int][]image.Rectangle{}
+	var roots []int
+	for i, spoke := range spokes {
+		root := find(i)
+		if _, found := groupsByRoot[root]; !found {
+			roots = append(roots, root)
+		}
+		groupsByRoot[root] = append(groupsByRoot[root], spoke)
+	}
+
+	var groups [][]image.Rectangle
+	for _, root := range roots {
+		groups = append(groups, groupsByRoot[root])
+	}
+	sort.SliceStable(groups, func(i, j int) bool {
+		return groups[i][0].Min.Y < groups[j][0].Min.Y
+	})
+
+	return groups
+}
+
+// fitCircle returns the circle that best fits the points in the least squares sense (Kåsa fit): the
+// circle x² + y² + ax + by + c = 0 minimizing the algebraic distance of the points. Returns false when
+// the points are too few or lie on a line.
+func fitCircle(points []point) (point, float64, bool) {
+	if len(points) < 3 {
+		return point{}, 0, false
+	}
+
+	// Points are centered on their mean to keep the normal equations well conditioned
+	var mean point
+	for _, p := range points {
+		mean.x += p.x
+		mean.y += p.y
+	}
+	mean.x /= float64(len(points))
+	mean.y /= float64(len(points))
+
+	var sxx, sxy, syy, sxz, syz float64
+	for _, p := range points {
+		x, y := p.x-mean.x, p.y-mean.y
+		z := x*x + y*y
+		sxx += x * x
+		sxy += x * y
+		syy += y * y
+		sxz += x * z
+		syz += y * z
+	}
+
+	// With centered points the normal equations for a and b are independent of c
+	determinant := sxx*syy - sxy*sxy
+	if math.Abs(determinant) < 1e-9 {
+		return point{}, 0, false
+	}
+	a := -(sxz*syy - syz*sxy) / determinant
+	b := -(syz*sxx - sxz*sxy) / determinant
+	c := -(sxx + syy) / float64(len(points))
+
+	radiusSquared := (a*a+b*b)/4 - c
+	if radiusSquared <= 0 {
+		return point{}, 0, false
+	}
+
+	return point{x: mean.x - a/2, y: mean.y - b/2}, math.Sqrt(radiusSquared), true
+}
+
+// getInkMask returns a binary mask of the searchRect region where pixels whose gray value differs from
+// the background by more than SpinnerInkThreshold are set.
+func (d *SpinnerDetector) getInkMask(imageMat gocv.Mat, searchRect image.Rectangle) (gocv.Mat, error) {
+	background, err := util.GetDominantLevel(imageMat, searchRect)
+	if err != nil {
+		return gocv.Mat{}, stacktrace.Propagate(err, "failed to get background level")
+	}
+
+	grayMat, err := util.GetGrayRegion(imageMat, searchRect)
+	if err != nil {
+		return gocv.Mat{}, stacktrace.Propagate(err, "failed to get gray region")
+	}
+	defer grayMat.Close()
+
+	backgroundMat := gocv.NewMatWithSizeFromScalar(gocv.NewScalar(float64(background), 0, 0, 0), grayMat.Rows(), grayMat.Cols(), gocv.MatTypeCV8U)
+	defer backgroundMat.Close()
+
+	inkMat := gocv.NewMat()
+	err = gocv.AbsDiff(grayMat, backgroundMat, &inkMat)
+	if err != nil {
+		inkMat.Close()
+		return gocv.Mat{}, stacktrace.Propagate(err, "failed to subtract background")
+	}
+	gocv.Threshold(inkMat, &inkMat, float32(d.config.SpinnerInkThreshold), 255, gocv.ThresholdBinary)
+
+	return inkMat, nil
+}
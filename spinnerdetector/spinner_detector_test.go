@@ -0,0 +1,183 @@
+package spinnerdetector_test
+
+import (
+	"image"
+	"testing"
+
+	"github.com/rogeriofbrito/go-insta-scraper-v2/config"
+	"github.com/rogeriofbrito/go-insta-scraper-v2/spinnerdetector"
+	"gocv.io/x/gocv"
+)
+
+func newConfig() *config.Config {
+	return &config.Config{
+		SpinnerSearchRect:      image.Rect(0, 0, 888, 300),
+		SpinnerInkThreshold:    8,
+		SpinnerMaxSpokeLength:  24,
+		SpinnerMinSpokes:       5,
+		SpinnerMinRadius:       10,
+		SpinnerMaxRadius:       30,
+		SpinnerRadiusTolerance: 0.25,
+	}
+}
+
+func TestSpinnerDetector_Find_DiverseCases(t *testing.T) {
+	tests := []struct {
+		name          string
+		imagePath     string
+		flags         gocv.IMReadFlag
+		expectedRect  image.Rectangle
+		expectedFound bool
+	}{
+		{
+			name:          "spinner",
+			imagePath:     "testdata/spinner.png",
+			flags:         gocv.IMReadColor,
+			expectedRect:  image.Rect(415, 196, 473, 254),
+			expectedFound: true,
+		},
+		{
+			name:          "spinner_grayscale",
+			imagePath:     "testdata/spinner.png",
+			flags:         gocv.IMReadGrayScale,
+			expectedRect:  image.Rect(415, 196, 473, 254),
+			expectedFound: true,
+		},
+		{
+			name:          "spinner_with_faint_spokes",
+			imagePath:     "testdata/spinner_faint.png",
+			flags:         gocv.IMReadColor,
+			expectedRect:  image.Rect(415, 222, 473, 254),
+			expectedFound: true,
+		},
+		{
+			name:          "text_line_is_not_a_spinner",
+			imagePath:     "testdata/no_spinner.png",
+			flags:         gocv.IMReadColor,
+			expectedFound: false,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			imageMat := gocv.IMRead(tc.imagePath, tc.flags)
+			if imageMat.Empty() {
+				t.Fatalf("failed to load image: %s", tc.imagePath)
+			}
+			defer imageMat.Close()
+
+			d := spinnerdetector.NewSpinnerDetector(newConfig())
+
+			rect, found, err := d.Find(imageMat)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if found != tc.expectedFound {
+				t.Fatalf("Find() found = %v; expected %v", found, tc.expectedFound)
+			}
+			if found && rect != tc.expectedRect {
+				t.Errorf("Find() = %v; expected %v", rect, tc.expectedRect)
+			}
+		})
+	}
+}
+
+func TestFindSpinner_DiverseCases(t *testing.T) {
+	// Components of a row above the spinner area
+	rowComponents := []image.Rectangle{
+		image.Rect(128, 70, 136, 76),
+		image.Rect(367, 86, 384, 105),
+	}
+
+	tests := []struct {
+		name          string
+		spokes        []image.Rectangle
+		expectedRect  image.Rectangle
+		expectedFound bool
+	}{
+		{
+			name: "eight_spokes",
+			spokes: append([]image.Rectangle{
+				image.Rect(441, 196, 447, 214),
+				image.Rect(422, 203, 437, 218),
+				image.Rect(451, 203, 466, 218),
+				image.Rect(415, 222, 433, 228),
+				image.Rect(455, 222, 473, 228),
+				image.Rect(422, 232, 437, 247),
+				image.Rect(451, 232, 466, 247),
+				image.Rect(441, 236, 447, 254),
+			}, rowComponents...),
+			expectedRect:  image.Rect(415, 196, 473, 254),
+			expectedFound: true,
+		},
+		{
+			name: "half_of_the_spokes_missed",
+			spokes: append([]image.Rectangle{
+				image.Rect(415, 222, 433, 228),
+				image.Rect(455, 222, 473, 228),
+				image.Rect(422, 232, 437, 247),
+				image.Rect(451, 232, 466, 247),
+				image.Rect(441, 236, 447, 254),
+			}, rowComponents...),
+			expectedRect:  image.Rect(415, 222, 473, 254),
+			expectedFound: true,
+		},
+		{
+			name: "too_few_spokes",
+			spokes: []image.Rectangle{
+				image.Rect(415, 222, 433, 228),
+				image.Rect(455, 222, 473, 228),
+				image.Rect(441, 236, 447, 254),
+			},
+			expectedFound: false,
+		},
+		{
+			name: "straight_text_line",
+			spokes: []image.Rectangle{
+				image.Rect(380, 220, 389, 235),
+				image.Rect(394, 220, 403, 235),
+				image.Rect(408, 220, 417, 235),
+				image.Rect(422, 220, 431, 235),
+				image.Rect(436, 220, 445, 235),
+				image.Rect(450, 220, 459, 235),
+			},
+			expectedFound: false,
+		},
+		{
+			name: "text_line_with_uneven_glyphs",
+			spokes: []image.Rectangle{
+				image.Rect(380, 220, 389, 235),
+				image.Rect(394, 224, 403, 235),
+				image.Rect(408, 220, 417, 239),
+				image.Rect(422, 224, 431, 235),
+				image.Rect(436, 220, 445, 235),
+				image.Rect(450, 224, 459, 239),
+			},
+			expectedFound: false,
+		},
+		{
+			name: "circle_larger_than_max_radius",
+			spokes: []image.Rectangle{
+				image.Rect(497, 222, 503, 228),
+				image.Rect(379, 222, 385, 228),
+				image.Rect(438, 281, 444, 287),
+				image.Rect(438, 163, 444, 169),
+				image.Rect(479, 263, 485, 269),
+				image.Rect(397, 181, 403, 187),
+			},
+			expectedFound: false,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			rect, found := spinnerdetector.FindSpinner(tc.spokes, newConfig())
+			if found != tc.expectedFound {
+				t.Fatalf("FindSpinner() found = %v (%v); expected %v", found, rect, tc.expectedFound)
+			}
+			if found && rect != tc.expectedRect {
+				t.Errorf("FindSpinner() = %v; expected %v", rect, tc.expectedRect)
+			}
+		})
+	}
+}
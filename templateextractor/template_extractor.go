@@ -0,0 +1,232 @@
+package templateextractor
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"math"
+	"path/filepath"
+
+	"github.com/palantir/stacktrace"
+	"github.com/rogeriofbrito/go-insta-scraper-v2/config"
+	"github.com/rogeriofbrito/go-insta-scraper-v2/templatepack"
+	"github.com/rogeriofbrito/go-insta-scraper-v2/tesseractocr"
+	"github.com/rogeriofbrito/go-insta-scraper-v2/util"
+	"gocv.io/x/gocv"
+)
+
+// labelFillOffset is the distance, in pixels, from the label to the point where the button fill color is sampled.
+const labelFillOffset = 3
+
+// maxButtonGrowth is how far a button may extend past each side of its label, in label widths horizontally
+// and in label heights vertically. A fill that extends further is not a button.
+const maxButtonGrowth = 2
+
+// NewTemplateExtractor creates a new TemplateExtractor. tocr is used to locate button labels
+// and should not restrict the characters it recognizes (see tesseractocr.NewTemplateLabelTesseractOcr).
+func NewTemplateExtractor(config *config.Config, tocr *tesseractocr.TesseractOcr) *TemplateExtractor {
+	return &TemplateExtractor{
+		config: config,
+		tocr:   tocr,
+	}
+}
+
+// TemplateExtractor crops button templates from screenshots and writes them into template packs.
+type TemplateExtractor struct {
+	config *config.Config
+	tocr   *tesseractocr.TesseractOcr
+}
+
+// ExtractFromRect crops the button at rect of the screenshot and writes it into the template pack at
+// packDirPath as <templateName>.png, updating the pack manifest. Returns the path of the written template.
+func (te *TemplateExtractor) ExtractFromRect(screenshotPath string, rect image.Rectangle, packDirPath, templateName string) (string, error) {
+	screenshotMat, err := te.readImage(screenshotPath)
+	if err != nil {
+		return "", stacktrace.Propagate(err, "failed to read screenshot image")
+	}
+	defer screenshotMat.Close()
+
+	if !rect.In(image.Rect(0, 0, screenshotMat.Cols(), screenshotMat.Rows())) || rect.Empty() {
+		return "", stacktrace.NewError("rect %v is not inside screenshot %s", rect, screenshotPath)
+	}
+
+	source := &templatepack.Source{Screenshot: screenshotPath, Rect: rect}
+	return te.writeTemplate(screenshotMat, source, packDirPath, templateName)
+}
+
+// ExtractFromLabel locates, with OCR over the right-hand column of the screenshot, the first button
+// whose label is label (e.g. "Seguir"), crops it and writes it into the template pack at packDirPath
+// as <templateName>.png, updating the pack manifest. Returns the path of the written template.
+func (te *TemplateExtractor) ExtractFromLabel(ctx context.Context, screenshotPath, label, packDirPath, templateName string) (string, error) {
+	screenshotMat, err := te.readImage(screenshotPath)
+	if err != nil {
+		return "", stacktrace.Propagate(err, "failed to read screenshot image")
+	}
+	defer screenshotMat.Close()
+
+	labelRect, err := te.locateLabel(ctx, screenshotMat, label)
+	if err != nil {
+		return "", stacktrace.Propagate(err, "failed to locate label %q in %s", label, screenshotPath)
+	}
+
+	buttonRect, err := te.GetButtonRect(screenshotMat, labelRect)
+	if err != nil {
+		return "", stacktrace.Propagate(err, "failed to find the button of label %q in %s", label, screenshotPath)
+	}
+
+	source := &templatepack.Source{Screenshot: screenshotPath, Rect: buttonRect, Label: label}
+	return te.writeTemplate(screenshotMat, source, packDirPath, templateName)
+}
+
+func (te *TemplateExtractor) readImage(imagePath string) (gocv.Mat, error) {
+	imageMat := gocv.IMRead(imagePath, gocv.IMReadColor)
+	if imageMat.Empty() {
+		return gocv.Mat{}, stacktrace.NewError("failed to read image at %s: image empty", imagePath)
+	}
+
+	return imageMat, nil
+}
+
+// locateLabel OCRs the column of the screenshot where buttons are and returns the bounding box
+// of the first occurrence of label, in screenshot coordinates.
+func (te *TemplateExtractor) locateLabel(ctx context.Context, screenshotMat gocv.Mat, label string) (image.Rectangle, error) {
+	searchRect := te.config.ReferencePointsSearchRect
+	columnRect := image.Rect(searchRect.Min.X, searchRect.Min.Y, screenshotMat.Cols(), screenshotMat.Rows()).
+		Intersect(image.Rect(0, 0, screenshotMat.Cols(), screenshotMat.Rows()))
+	if columnRect.Empty() {
+		return image.Rectangle{}, stacktrace.NewError("button column %v is outside the screenshot", columnRect)
+	}
+
+	words, err := te.tocr.ReadWords(ctx, screenshotMat, columnRect)
+	if err != nil {
+		return image.Rectangle{}, stacktrace.Propagate(err, "failed to execute tesseract ocr over %v", columnRect)
+	}
+
+	labelRect, found := tesseractocr.FindPhrase(words, label)
+	if !found {
+		return image.Rectangle{}, stacktrace.NewError("label %q not found among %d words", label, len(words))
+	}
+
+	return labelRect, nil
+}
+
+// GetButtonRect grows the label bounding box over the button fill color, sampled just left of the label,
+// until the button edges are reached. It fails when the fill is the page background (an outlined or
+// transparent button, which has to be extracted with ExtractFromRect) and when the fill extends more than
+// maxButtonGrowth label sizes past the label or reaches the screenshot edge, since the rect would then cover
+// much more than a button.
+func (te *TemplateExtractor) GetButtonRect(screenshotMat gocv.Mat, labelRect image.Rectangle) (image.Rectangle, error) {
+	bounds := image.Rect(0, 0, screenshotMat.Cols(), screenshotMat.Rows())
+	fillX := max(labelRect.Min.X-labelFillOffset, 0)
+	centerY := (labelRect.Min.Y + labelRect.Max.Y) / 2
+	fill := screenshotMat.GetVecbAt(centerY, fillX)
+
+	background, err := util.GetDominantLevel(screenshotMat, bounds)
+	if err != nil {
+		return image.Rectangle{}, stacktrace.Propagate(err, "failed to get background level")
+	}
+	fillLevel := 0.114*float64(fill[0]) + 0.587*float64(fill[1]) + 0.299*float64(fill[2])
+	if math.Abs(fillLevel-float64(background)) <= float64(te.config.UniformThresold) {
+		return image.Rectangle{}, stacktrace.NewError(
+			"button fill at %v is the page background, the button is outlined or transparent: extract it from a rect",
+			image.Pt(fillX, centerY),
+		)
+	}
+
+	isFill := func(x, y int) bool {
+		if !image.Pt(x, y).In(bounds) {
+			return false
+		}
+		pixel := screenshotMat.GetVecbAt(y, x)
+		for c := range fill {
+			if math.Abs(float64(pixel[c])-float64(fill[c])) > float64(te.config.UniformThresold) {
+				return false
+			}
+		}
+		return true
+	}
+
+	limits := image.Rect(
+		labelRect.Min.X-maxButtonGrowth*labelRect.Dx(), labelRect.Min.Y-maxButtonGrowth*labelRect.Dy(),
+		labelRect.Max.X+maxButtonGrowth*labelRect.Dx(), labelRect.Max.Y+maxButtonGrowth*labelRect.Dy(),
+	)
+	left := fillX
+	for left > limits.Min.X && isFill(left-1, centerY) {
+		left--
+	}
+	right := labelRect.Max.X
+	for right < limits.Max.X && isFill(right, centerY) {
+		right++
+	}
+	top := centerY
+	for top > limits.Min.Y && isFill(fillX, top-1) {
+		top--
+	}
+	bottom := centerY
+	for bottom < limits.Max.Y && isFill(fillX, bottom) {
+		bottom++
+	}
+
+	buttonRect := image.Rect(left, top, right, bottom)
+	if left == limits.Min.X || right == limits.Max.X || top == limits.Min.Y || bottom == limits.Max.Y {
+		return image.Rectangle{}, stacktrace.NewError("button fill around label %v extends past %v, it is not a button", labelRect, limits)
+	}
+	if left == bounds.Min.X || right == bounds.Max.X || top == bounds.Min.Y || bottom == bounds.Max.Y {
+		return image.Rectangle{}, stacktrace.NewError("button fill around label %v reaches the screenshot edge at %v", labelRect, buttonRect)
+	}
+
+	// Keep the anti-aliased button edge as part of the template
+	return buttonRect.Inset(-1), nil
+}
+
+// writeTemplate crops the source region of the screenshot, masks the background visible around
+// the button corners using an alpha channel, writes it into the pack and updates the manifest.
+func (te *TemplateExtractor) writeTemplate(screenshotMat gocv.Mat, source *templatepack.Source, packDirPath, templateName string) (string, error) {
+	manifest, err := templatepack.LoadManifest(packDirPath)
+	if err != nil {
+		return "", stacktrace.Propagate(err, "failed to load manifest of pack %s", packDirPath)
+	}
+
+	buttonMat := screenshotMat.Region(source.Rect)
+	defer buttonMat.Close()
+
+	maskMat, err := util.GetCornerBackgroundMask(buttonMat, te.config.UniformThresold)
+	if err != nil {
+		return "", stacktrace.Propagate(err, "failed to build template mask")
+	}
+	defer maskMat.Close()
+
+	channels := gocv.Split(buttonMat)
+	channels = append(channels, maskMat)
+	templateMat := gocv.NewMat()
+	defer templateMat.Close()
+	err = gocv.Merge(channels, &templateMat)
+	for _, channel := range channels[:len(channels)-1] {
+		channel.Close()
+	}
+	if err != nil {
+		return "", stacktrace.Propagate(err, "failed to merge template alpha channel")
+	}
+
+	templateFile := fmt.Sprintf("%s.png", templateName)
+	templatePath := filepath.Join(packDirPath, templateFile)
+	writeSuccess := gocv.IMWrite(templatePath, templateMat)
+	if !writeSuccess {
+		return "", stacktrace.NewError("failed to write mat at path %s", templatePath)
+	}
+
+	masked := gocv.CountNonZero(maskMat) < maskMat.Rows()*maskMat.Cols()
+	manifest.Put(templatepack.Entry{
+		Name:   templateName,
+		File:   templateFile,
+		Masked: masked,
+		Source: source,
+	})
+
+	err = manifest.Save(packDirPath)
+	if err != nil {
+		return "", stacktrace.Propagate(err, "failed to save manifest of pack %s", packDirPath)
+	}
+
+	return templatePath, nil
+}
@@ -0,0 +1,176 @@
+package templateextractor_test
+
+import (
+	"image"
+	"image/color"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/rogeriofbrito/go-insta-scraper-v2/config"
+	"github.com/rogeriofbrito/go-insta-scraper-v2/templateextractor"
+	"github.com/rogeriofbrito/go-insta-scraper-v2/templatepack"
+	"github.com/rogeriofbrito/go-insta-scraper-v2/tesseractocr"
+	"gocv.io/x/gocv"
+)
+
+func TestTemplateExtractor_ExtractFromRect_DiverseCases(t *testing.T) {
+	packDirPath := filepath.Join(os.TempDir(), "go-insta-scraper-v2-test-extracted-pack")
+	_ = os.RemoveAll(packDirPath)
+	defer os.RemoveAll(packDirPath)
+	if err := os.MkdirAll(packDirPath, 0777); err != nil {
+		t.Fatalf("failed to create pack dir: %v", err)
+	}
+
+	cfg := config.Config{
+		WorkingDirPath:  "/tmp/go-insta-scraper",
+		UniformThresold: 5,
+	}
+
+	tests := []struct {
+		name           string
+		screenshotPath string
+		rect           image.Rectangle
+		templateName   string
+		expectedMasked bool
+		expectErr      bool
+	}{
+		{
+			name:           "rounded_button_is_masked",
+			screenshotPath: "testdata/button_screenshot.png",
+			rect:           image.Rect(40, 30, 160, 70),
+			templateName:   "follow",
+			expectedMasked: true,
+		},
+		{
+			name:           "button_interior_is_not_masked",
+			screenshotPath: "testdata/button_screenshot.png",
+			rect:           image.Rect(60, 40, 140, 60),
+			templateName:   "following",
+			expectedMasked: false,
+		},
+		{
+			name:           "rect_outside_screenshot_fails",
+			screenshotPath: "testdata/button_screenshot.png",
+			rect:           image.Rect(150, 30, 250, 70),
+			templateName:   "message",
+			expectErr:      true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			te := templateextractor.NewTemplateExtractor(&cfg, tesseractocr.NewTemplateLabelTesseractOcr(&cfg))
+
+			templatePath, err := te.ExtractFromRect(tc.screenshotPath, tc.rect, packDirPath, tc.templateName)
+			if tc.expectErr {
+				if err == nil {
+					t.Fatalf("expected error but got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			templateMat := gocv.IMRead(templatePath, gocv.IMReadUnchanged)
+			if templateMat.Empty() {
+				t.Fatalf("failed to load template: %s", templatePath)
+			}
+			defer templateMat.Close()
+
+			if templateMat.Channels() != 4 || templateMat.Cols() != tc.rect.Dx() || templateMat.Rows() != tc.rect.Dy() {
+				t.Errorf("template = %dx%dx%d; expected %dx%dx4",
+					templateMat.Cols(), templateMat.Rows(), templateMat.Channels(), tc.rect.Dx(), tc.rect.Dy())
+			}
+
+			manifest, err := templatepack.LoadManifest(packDirPath)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			entry, found := manifest.Get(tc.templateName)
+			if !found {
+				t.Fatalf("template %s not found in manifest", tc.templateName)
+			}
+			if entry.Masked != tc.expectedMasked {
+				t.Errorf("manifest entry masked = %v; expected %v", entry.Masked, tc.expectedMasked)
+			}
+			if entry.Source == nil || entry.Source.Rect != tc.rect || entry.Source.Screenshot != tc.screenshotPath {
+				t.Errorf("manifest entry source = %+v; expected screenshot %s and rect %v", entry.Source, tc.screenshotPath, tc.rect)
+			}
+		})
+	}
+}
+
+// newButtonMat returns a light BGR screenshot with a filled button of the given gray level at buttonRect and
+// a white label at labelRect.
+func newButtonMat(size image.Point, buttonRect image.Rectangle, fill uint8, labelRect image.Rectangle) gocv.Mat {
+	mat := gocv.NewMatWithSizeFromScalar(gocv.NewScalar(250, 250, 250, 0), size.Y, size.X, gocv.MatTypeCV8UC3)
+	gocv.Rectangle(&mat, buttonRect, color.RGBA{R: fill, G: fill, B: fill, A: 255}, -1)
+	gocv.Rectangle(&mat, labelRect, color.RGBA{R: 255, G: 255, B: 255, A: 255}, -1)
+	return mat
+}
+
+func TestTemplateExtractor_GetButtonRect_DiverseCases(t *testing.T) {
+	cfg := config.Config{UniformThresold: 5}
+
+	tests := []struct {
+		name         string
+		buttonRect   image.Rectangle
+		fill         uint8
+		labelRect    image.Rectangle
+		expectedRect image.Rectangle
+		expectErr    bool
+	}{
+		{
+			name:         "filled_button_with_its_edge",
+			buttonRect:   image.Rect(40, 20, 160, 60),
+			fill:         60,
+			labelRect:    image.Rect(80, 32, 120, 48),
+			expectedRect: image.Rect(39, 19, 161, 61),
+		},
+		{
+			name:       "outlined_button_fill_is_the_background",
+			buttonRect: image.Rect(40, 20, 160, 60),
+			fill:       250,
+			labelRect:  image.Rect(80, 32, 120, 48),
+			expectErr:  true,
+		},
+		{
+			name:       "fill_much_wider_than_the_label",
+			buttonRect: image.Rect(10, 20, 390, 60),
+			fill:       60,
+			labelRect:  image.Rect(180, 32, 200, 48),
+			expectErr:  true,
+		},
+		{
+			name:       "fill_reaching_the_screenshot_edge",
+			buttonRect: image.Rect(0, 20, 160, 60),
+			fill:       60,
+			labelRect:  image.Rect(80, 32, 120, 48),
+			expectErr:  true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			screenshotMat := newButtonMat(image.Pt(400, 100), tc.buttonRect, tc.fill, tc.labelRect)
+			defer screenshotMat.Close()
+
+			te := templateextractor.NewTemplateExtractor(&cfg, nil)
+			rect, err := te.GetButtonRect(screenshotMat, tc.labelRect)
+			if tc.expectErr {
+				if err == nil {
+					t.Fatalf("GetButtonRect(%v) = %v; expected an error", tc.labelRect, rect)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if rect != tc.expectedRect {
+				t.Errorf("GetButtonRect(%v) = %v; expected %v", tc.labelRect, rect, tc.expectedRect)
+			}
+		})
+	}
+}
@@ -1,6 +1,7 @@
 package templatematcher
 
 import (
+	"context"
 	"image"
 	"image/color"
 
@@ -21,21 +22,57 @@ type TemplateMatcher struct {
 	config *config.Config
 }
 
+// MatchStats describes the best candidate region found for a template, whether or not
+// it reached the matching threshold. It is used to detect templates that stopped matching.
+type MatchStats struct {
+	BestScore float32         // Highest similarity score found in the image
+	BestRect  image.Rectangle // Region of the image where the highest score was found
+}
+
 // GetMatches finds all regions in the image Mat that match the template Mat.
-// Returns a slice of rectangles representing the matched regions.
-func (tm *TemplateMatcher) GetMatches(imageMat, templateMat gocv.Mat) ([]image.Rectangle, error) {
+// maskMat selects the template pixels that take part in the matching (non-zero pixels);
+// pass an empty Mat to use every pixel of the template.
+// Returns a slice of rectangles representing the matched regions. Matching stops with an error once ctx is done.
+func (tm *TemplateMatcher) GetMatches(ctx context.Context, imageMat, templateMat, maskMat gocv.Mat) ([]image.Rectangle, error) {
+	matches, _, err := tm.GetMatchesWithStats(ctx, imageMat, templateMat, maskMat)
+	return matches, err
+}
+
+// GetMatchesWithStats works like GetMatches and also returns the best candidate region,
+// which is reported even when its score is below the matching threshold.
+func (tm *TemplateMatcher) GetMatchesWithStats(ctx context.Context, imageMat, templateMat, maskMat gocv.Mat) ([]image.Rectangle, MatchStats, error) {
 	// Prepare a result matrix to store match results
 	result := gocv.NewMat()
 	defer result.Close()
 
 	// Perform template matching
-	err := gocv.MatchTemplate(imageMat, templateMat, &result, tm.config.MatchTemplateMethod, gocv.NewMat())
+	err := gocv.MatchTemplate(imageMat, templateMat, &result, tm.config.MatchTemplateMethod, maskMat)
 	if err != nil {
-		return nil, stacktrace.Propagate(err, "failed to match template")
+		return nil, MatchStats{}, stacktrace.Propagate(err, "failed to match template")
+	}
+
+	// Masked normed methods yield NaN/inf where the masked window has no variance
+	if !maskMat.Empty() {
+		err = result.PatchNaNs()
+		if err != nil {
+			return nil, MatchStats{}, stacktrace.Propagate(err, "failed to patch NaNs in match result")
+		}
+		gocv.Threshold(result, &result, 1, 0, gocv.ThresholdToZeroInv)
+	}
+
+	// Record the best candidate before matches start being suppressed
+	_, bestVal, _, bestLoc := gocv.MinMaxLoc(result)
+	stats := MatchStats{
+		BestScore: bestVal,
+		BestRect:  image.Rect(bestLoc.X, bestLoc.Y, bestLoc.X+templateMat.Cols(), bestLoc.Y+templateMat.Rows()),
 	}
 
 	matches := []image.Rectangle{}
 	for {
+		if ctx.Err() != nil {
+			return nil, MatchStats{}, stacktrace.Propagate(ctx.Err(), "stopped after %d matches", len(matches))
+		}
+
 		// Find the location and value of the best match in the result matrix
 		_, maxVal, _, maxLoc := gocv.MinMaxLoc(result)
 
@@ -51,12 +88,12 @@ func (tm *TemplateMatcher) GetMatches(imageMat, templateMat gocv.Mat) ([]image.R
 		// Suppress this match in the result matrix to avoid duplicate detections
 		err := gocv.Rectangle(&result, match, color.RGBA{0, 0, 0, 0}, -1)
 		if err != nil {
-			return nil, stacktrace.Propagate(err, "failed to suppress match")
+			return nil, MatchStats{}, stacktrace.Propagate(err, "failed to suppress match")
 		}
 
 		// Add the matched rectangle to the results
 		matches = append(matches, match)
 	}
 
-	return matches, nil
+	return matches, stats, nil
 }
@@ -0,0 +1,109 @@
+package templatepack
+
+import (
+	"encoding/json"
+	"errors"
+	"image"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/palantir/stacktrace"
+)
+
+// ManifestFileName is the name of the manifest file inside a template pack directory.
+const ManifestFileName = "manifest.json"
+
+// Manifest lists the templates of a template pack (a directory such as template/pt_BR).
+type Manifest struct {
+	Templates []Entry `json:"templates"` // Templates of the pack, sorted by name
+}
+
+// Entry describes a single template of a pack.
+type Entry struct {
+	Name   string  `json:"name"`             // Template name (e.g. follow, following, message)
+	File   string  `json:"file"`             // Template image file, relative to the pack directory
+	Masked bool    `json:"masked"`           // Whether the image has an alpha channel used as matching mask
+	Source *Source `json:"source,omitempty"` // Where the template was cropped from, when known
+}
+
+// Source records the screenshot region a template was cropped from.
+type Source struct {
+	Screenshot string          `json:"screenshot"` // Path of the screenshot
+	Rect       image.Rectangle `json:"rect"`       // Cropped region of the screenshot
+	Label      string          `json:"label"`      // Button label used to locate the region, empty if given by hand
+}
+
+// LoadManifest reads the manifest of the template pack at packDirPath.
+// Returns an empty manifest if the pack has no manifest yet.
+func LoadManifest(packDirPath string) (*Manifest, error) {
+	manifestPath := filepath.Join(packDirPath, ManifestFileName)
+	manifestBytes, err := os.ReadFile(manifestPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return &Manifest{}, nil
+	}
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "failed to read manifest at %s", manifestPath)
+	}
+
+	manifest := &Manifest{}
+	err = json.Unmarshal(manifestBytes, manifest)
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "failed to parse manifest at %s", manifestPath)
+	}
+
+	return manifest, nil
+}
+
+// Save writes the manifest into the template pack at packDirPath.
+func (m *Manifest) Save(packDirPath string) error {
+	manifestPath := filepath.Join(packDirPath, ManifestFileName)
+	manifestBytes, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return stacktrace.Propagate(err, "failed to encode manifest")
+	}
+
+	err = os.WriteFile(manifestPath, append(manifestBytes, '\n'), 0644)
+	if err != nil {
+		return stacktrace.Propagate(err, "failed to write manifest at %s", manifestPath)
+	}
+
+	return nil
+}
+
+// Get returns the entry of the template with the given name.
+func (m *Manifest) Get(name string) (Entry, bool) {
+	for _, entry := range m.Templates {
+		if entry.Name == name {
+			return entry, true
+		}
+	}
+
+	return Entry{}, false
+}
+
+// Put adds the entry to the manifest, replacing the entry with the same name if there is one.
+func (m *Manifest) Put(entry Entry) {
+	for i := range m.Templates {
+		if m.Templates[i].Name == entry.Name {
+			m.Templates[i] = entry
+			return
+		}
+	}
+
+	m.Templates = append(m.Templates, entry)
+	sort.Slice(m.Templates, func(i, j int) bool {
+		return m.Templates[i].Name < m.Templates[j].Name
+	})
+}
+
+// TemplatePath returns the path of the image of the template with the given name
+// inside the template pack at packDirPath.
+func (m *Manifest) TemplatePath(packDirPath string, name string) (string, error) {
+	entry, found := m.Get(name)
+	if !found {
+		return "", stacktrace.NewError("template %s not found in manifest of pack %s", name, packDirPath)
+	}
+
+	return filepath.Join(packDirPath, entry.File), nil
+}
@@ -0,0 +1,114 @@
+package templatepack_test
+
+import (
+	"image"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/rogeriofbrito/go-insta-scraper-v2/templatepack"
+)
+
+func TestLoadManifest_DiverseCases(t *testing.T) {
+	tests := []struct {
+		name          string
+		packDirPath   string
+		expectedNames []string
+		expectErr     bool
+	}{
+		{
+			name:          "existing_manifest",
+			packDirPath:   "testdata/pack",
+			expectedNames: []string{"follow", "following", "message"},
+		},
+		{
+			name:          "missing_manifest_returns_empty",
+			packDirPath:   "testdata/missing_pack",
+			expectedNames: nil,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			manifest, err := templatepack.LoadManifest(tc.packDirPath)
+			if tc.expectErr {
+				if err == nil {
+					t.Fatalf("expected error but got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			var names []string
+			for _, entry := range manifest.Templates {
+				names = append(names, entry.Name)
+			}
+			if len(names) != len(tc.expectedNames) {
+				t.Fatalf("LoadManifest(%s) names = %v; expected %v", tc.packDirPath, names, tc.expectedNames)
+			}
+			for i := range names {
+				if names[i] != tc.expectedNames[i] {
+					t.Fatalf("LoadManifest(%s) names = %v; expected %v", tc.packDirPath, names, tc.expectedNames)
+				}
+			}
+		})
+	}
+}
+
+func TestManifest_PutSaveAndTemplatePath(t *testing.T) {
+	packDirPath := filepath.Join(os.TempDir(), "go-insta-scraper-v2-test-pack")
+	_ = os.RemoveAll(packDirPath)
+	defer os.RemoveAll(packDirPath)
+	if err := os.MkdirAll(packDirPath, 0777); err != nil {
+		t.Fatalf("failed to create pack dir: %v", err)
+	}
+
+	manifest, err := templatepack.LoadManifest("testdata/pack")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Replacing an existing entry keeps a single entry with that name
+	manifest.Put(templatepack.Entry{
+		Name:   "follow",
+		File:   "follow_v2.png",
+		Masked: true,
+		Source: &templatepack.Source{Screenshot: "screenshot.png", Rect: image.Rect(629, 482, 840, 553), Label: "Seguir"},
+	})
+	// New entries are kept sorted by name
+	manifest.Put(templatepack.Entry{Name: "dismiss", File: "dismiss.png"})
+
+	if err := manifest.Save(packDirPath); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	saved, err := templatepack.LoadManifest(packDirPath)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(saved.Templates) != 4 || saved.Templates[0].Name != "dismiss" {
+		t.Fatalf("saved templates = %+v; expected 4 templates starting with dismiss", saved.Templates)
+	}
+
+	follow, found := saved.Get("follow")
+	if !found {
+		t.Fatalf("follow template not found after save")
+	}
+	if !follow.Masked || follow.Source == nil || follow.Source.Rect != image.Rect(629, 482, 840, 553) {
+		t.Errorf("follow entry = %+v; expected masked entry with source rect", follow)
+	}
+
+	templatePath, err := saved.TemplatePath(packDirPath, "follow")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if templatePath != filepath.Join(packDirPath, "follow_v2.png") {
+		t.Errorf("TemplatePath() = %s; expected %s", templatePath, filepath.Join(packDirPath, "follow_v2.png"))
+	}
+
+	if _, err := saved.TemplatePath(packDirPath, "missing"); err == nil {
+		t.Errorf("TemplatePath() of missing template: expected error but got nil")
+	}
+}
@@ -0,0 +1,160 @@
+package tesseractocr
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/color"
+
+	"github.com/palantir/stacktrace"
+	"github.com/rogeriofbrito/go-insta-scraper-v2/ocr"
+	"github.com/rogeriofbrito/go-insta-scraper-v2/util"
+	"gocv.io/x/gocv"
+)
+
+// ReadBatch implements ocr.BatchEngine: it stacks the parts of rects inside imageMat one below the other,
+// preprocessed when the engine has a preprocessor and with batchSpacing pixels of replicated background
+// around each, and reads the stacked image with a single Tesseract invocation. Each recognized word is mapped
+// back to the region it was read in by its line position, and its bounding box translated to imageMat
+// coordinates. Results are returned in the order of rects.
+func (t *TesseractOcr) ReadBatch(ctx context.Context, imageMat gocv.Mat, rects []image.Rectangle) ([]ocr.Result, error) {
+	if len(rects) == 0 {
+		return nil, nil
+	}
+
+	stackedMat, clippedRects, regions, err := t.stackRegions(imageMat, rects)
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "failed to stack %d regions", len(rects))
+	}
+	defer stackedMat.Close()
+
+	stackedBuffer, err := gocv.IMEncode(gocv.PNGFileExt, stackedMat)
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "failed to encode stacked regions")
+	}
+	defer stackedBuffer.Close()
+
+	hocr, err := t.run(ctx, stackedBuffer.GetBytes(), t.batchPsm, "hocr")
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "failed to execute tesseract ocr over %d stacked regions", len(rects))
+	}
+
+	words, err := ParseHocr(bytes.NewReader(hocr))
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "failed to parse hocr of stacked regions")
+	}
+
+	results := make([]ocr.Result, len(rects))
+	for i, regionWords := range SplitWordsByRegion(words, regions) {
+		for j := range regionWords {
+			regionWords[j] = regionWords[j].MapRects(func(wordRect image.Rectangle) image.Rectangle {
+				return t.mapRegionRect(wordRect.Sub(regions[i].Min)).Add(clippedRects[i].Min)
+			})
+		}
+		results[i] = ResultFromWords(regionWords)
+	}
+
+	return results, nil
+}
+
+// stackRegions copies the parts of rects inside imageMat, as returned by getRegion, into a single image,
+// one below the other in the order of rects, and returns it along with the clipped rects and where each
+// region was placed. Regions are aligned to the left and surrounded by batchSpacing pixels, plus whatever is
+// needed to reach the widest region, replicated from their edges so no seam is added between them.
+func (t *TesseractOcr) stackRegions(imageMat gocv.Mat, rects []image.Rectangle) (gocv.Mat, []image.Rectangle, []image.Rectangle, error) {
+	bounds := image.Rect(0, 0, imageMat.Cols(), imageMat.Rows())
+	clippedRects := make([]image.Rectangle, len(rects))
+	regionMats := make([]gocv.Mat, 0, len(rects))
+	defer func() {
+		for i := range regionMats {
+			regionMats[i].Close()
+		}
+	}()
+
+	width := 0
+	height := 0
+	for i, rect := range rects {
+		clippedRects[i], _ = util.ClipRect(rect, bounds)
+		if clippedRects[i].Empty() {
+			return gocv.Mat{}, nil, nil, stacktrace.NewError("failed to stack region %v: rect is outside the image", rect)
+		}
+
+		regionMat, err := t.getRegion(imageMat, clippedRects[i])
+		if err != nil {
+			return gocv.Mat{}, nil, nil, stacktrace.Propagate(err, "failed to get region %v", rect)
+		}
+		regionMats = append(regionMats, regionMat)
+
+		width = max(width, regionMat.Cols()+2*t.batchSpacing)
+		height += regionMat.Rows() + 2*t.batchSpacing
+	}
+
+	stackedMat := gocv.NewMatWithSize(height, width, regionMats[0].Type())
+	regions := make([]image.Rectangle, len(rects))
+	y := 0
+	for i, regionMat := range regionMats {
+		paddedRect := image.Rect(0, y, width, y+regionMat.Rows()+2*t.batchSpacing)
+		regions[i] = image.Rect(t.batchSpacing, y+t.batchSpacing, t.batchSpacing+regionMat.Cols(), y+t.batchSpacing+regionMat.Rows())
+		y = paddedRect.Max.Y
+
+		err := t.copyPaddedRegion(regionMat, stackedMat, paddedRect)
+		if err != nil {
+			stackedMat.Close()
+			return gocv.Mat{}, nil, nil, stacktrace.Propagate(err, "failed to stack region %v", rects[i])
+		}
+	}
+
+	return stackedMat, clippedRects, regions, nil
+}
+
+// copyPaddedRegion copies regionMat into paddedRect of stackedMat, batchSpacing pixels from its top left
+// corner, filling the rest of paddedRect by replicating the region edges.
+func (t *TesseractOcr) copyPaddedRegion(regionMat gocv.Mat, stackedMat gocv.Mat, paddedRect image.Rectangle) error {
+	paddedMat := gocv.NewMat()
+	defer paddedMat.Close()
+
+	right := paddedRect.Dx() - regionMat.Cols() - t.batchSpacing
+	err := gocv.CopyMakeBorder(regionMat, &paddedMat, t.batchSpacing, t.batchSpacing, t.batchSpacing, right, gocv.BorderReplicate, color.RGBA{})
+	if err != nil {
+		return stacktrace.Propagate(err, "failed to pad region")
+	}
+
+	stackedRegionMat := stackedMat.Region(paddedRect)
+	defer stackedRegionMat.Close()
+
+	paddedMat.CopyTo(&stackedRegionMat)
+
+	return nil
+}
+
+// SplitWordsByRegion groups words by the region they were read in: each word goes to the region whose
+// vertical extent is closest to the word's vertical center, so words read slightly above or below a region
+// (e.g. descenders) still belong to it. The result has one entry per region, in the order of regions,
+// with words in reading order.
+func SplitWordsByRegion(words []Word, regions []image.Rectangle) [][]Word {
+	regionWords := make([][]Word, len(regions))
+	if len(regions) == 0 {
+		return regionWords
+	}
+
+	for _, word := range words {
+		centerY := (word.Rect.Min.Y + word.Rect.Max.Y) / 2
+		closest := 0
+		closestDistance := -1
+		for i, region := range regions {
+			distance := 0
+			if centerY < region.Min.Y {
+				distance = region.Min.Y - centerY
+			} else if centerY >= region.Max.Y {
+				distance = centerY - region.Max.Y + 1
+			}
+			if closestDistance == -1 || distance < closestDistance {
+				closest = i
+				closestDistance = distance
+			}
+		}
+		regionWords[closest] = append(regionWords[closest], word)
+	}
+
+	return regionWords
+}
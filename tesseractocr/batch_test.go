@@ -0,0 +1,87 @@
+package tesseractocr_test
+
+import (
+	"image"
+	"testing"
+
+	"github.com/rogeriofbrito/go-insta-scraper-v2/tesseractocr"
+)
+
+func TestSplitWordsByRegion_DiverseCases(t *testing.T) {
+	// Three 36px tall username crops stacked with 16px of spacing around each
+	regions := []image.Rectangle{
+		image.Rect(16, 16, 456, 52),
+		image.Rect(16, 84, 456, 120),
+		image.Rect(16, 152, 456, 188),
+	}
+
+	tests := []struct {
+		name          string
+		words         []tesseractocr.Word
+		expectedTexts [][]string
+	}{
+		{
+			name:          "no_words",
+			words:         nil,
+			expectedTexts: [][]string{nil, nil, nil},
+		},
+		{
+			name: "one_word_per_region",
+			words: []tesseractocr.Word{
+				{Text: "matheusgonze1", Rect: image.Rect(20, 22, 210, 48), Line: 0},
+				{Text: "stephencurry30", Rect: image.Rect(20, 90, 230, 116), Line: 1},
+				{Text: "kvraco", Rect: image.Rect(20, 158, 110, 184), Line: 2},
+			},
+			expectedTexts: [][]string{{"matheusgonze1"}, {"stephencurry30"}, {"kvraco"}},
+		},
+		{
+			name: "region_without_text",
+			words: []tesseractocr.Word{
+				{Text: "matheusgonze1", Rect: image.Rect(20, 22, 210, 48), Line: 0},
+				{Text: "kvraco", Rect: image.Rect(20, 158, 110, 184), Line: 1},
+			},
+			expectedTexts: [][]string{{"matheusgonze1"}, nil, {"kvraco"}},
+		},
+		{
+			name: "word_box_overflowing_into_spacing",
+			words: []tesseractocr.Word{
+				{Text: "belightstore_", Rect: image.Rect(20, 60, 200, 122), Line: 0},
+			},
+			expectedTexts: [][]string{nil, {"belightstore_"}, nil},
+		},
+		{
+			name: "word_centered_in_spacing_goes_to_closest_region",
+			words: []tesseractocr.Word{
+				{Text: "noise", Rect: image.Rect(20, 124, 60, 134), Line: 0},
+			},
+			expectedTexts: [][]string{nil, {"noise"}, nil},
+		},
+		{
+			name: "several_words_in_a_region",
+			words: []tesseractocr.Word{
+				{Text: "naosalvo", Rect: image.Rect(20, 90, 150, 116), Line: 0},
+				{Text: ".", Rect: image.Rect(160, 108, 166, 116), Line: 0},
+			},
+			expectedTexts: [][]string{nil, {"naosalvo", "."}, nil},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			regionWords := tesseractocr.SplitWordsByRegion(tc.words, regions)
+			if len(regionWords) != len(regions) {
+				t.Fatalf("SplitWordsByRegion() returned %d groups; expected %d", len(regionWords), len(regions))
+			}
+			for i, words := range regionWords {
+				if len(words) != len(tc.expectedTexts[i]) {
+					t.Fatalf("SplitWordsByRegion()[%d] = %v; expected %v", i, words, tc.expectedTexts[i])
+				}
+				for j, word := range words {
+					if word.Text != tc.expectedTexts[i][j] {
+						t.Errorf("SplitWordsByRegion()[%d][%d] = %q; expected %q", i, j, word.Text, tc.expectedTexts[i][j])
+					}
+				}
+			}
+		})
+	}
+}
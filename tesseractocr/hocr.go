@@ -0,0 +1,198 @@
+package tesseractocr
+
+import (
+	"encoding/xml"
+	"image"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/palantir/stacktrace"
+)
+
+// Word is a word recognized by Tesseract, as described in its hOCR output.
+type Word struct {
+	Text       string          // Recognized text
+	Rect       image.Rectangle // Bounding box of the word in the OCR'd image
+	Confidence float64         // Word confidence, from 0 to 100
+	Line       int             // Index of the text line the word belongs to, from top to bottom
+	Chars      []Char          // Characters of the word, only when Tesseract was run with hocr_char_boxes=1
+}
+
+// Char is a character of a recognized word, as described by an ocrx_cinfo element of the hOCR output.
+type Char struct {
+	Text       string          // Recognized character
+	Rect       image.Rectangle // Bounding box of the character in the OCR'd image
+	Confidence float64         // Character confidence, from 0 to 100
+}
+
+// Add translates the word and its characters by p.
+func (w Word) Add(p image.Point) Word {
+	return w.MapRects(func(rect image.Rectangle) image.Rectangle {
+		return rect.Add(p)
+	})
+}
+
+// MapRects returns the word with its bounding box and the bounding boxes of its characters mapped by f
+// (e.g. from the coordinates of a preprocessed crop back to the screenshot).
+func (w Word) MapRects(f func(image.Rectangle) image.Rectangle) Word {
+	w.Rect = f(w.Rect)
+	if w.Chars != nil {
+		chars := make([]Char, len(w.Chars))
+		for i, char := range w.Chars {
+			char.Rect = f(char.Rect)
+			chars[i] = char
+		}
+		w.Chars = chars
+	}
+
+	return w
+}
+
+// ParseHocr reads the words of a Tesseract hOCR document, in reading order, along with their characters
+// when the document has character boxes. The text of a word with characters is made of its characters.
+func ParseHocr(r io.Reader) ([]Word, error) {
+	decoder := xml.NewDecoder(r)
+	decoder.Strict = false
+	decoder.AutoClose = xml.HTMLAutoClose
+	decoder.Entity = xml.HTMLEntity
+
+	var words []Word
+	line := -1
+	depth := 0
+	wordDepth := -1 // depth of the word element being read, -1 when outside a word
+	charDepth := -1 // depth of the character element being read, -1 when outside a character
+	var wordText strings.Builder
+	var charText strings.Builder
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, stacktrace.Propagate(err, "failed to decode hocr document")
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			depth++
+			switch getAttr(t, "class") {
+			case "ocr_line", "ocr_header", "ocr_caption", "ocr_textfloat":
+				line++
+			case "ocrx_word":
+				title := parseTitle(getAttr(t, "title"))
+				words = append(words, Word{
+					Rect:       title.bbox,
+					Confidence: title.wconf,
+					Line:       max(line, 0),
+				})
+				wordDepth = depth
+				wordText.Reset()
+			case "ocrx_cinfo":
+				if wordDepth == -1 {
+					break
+				}
+				title := parseTitle(getAttr(t, "title"))
+				word := &words[len(words)-1]
+				word.Chars = append(word.Chars, Char{
+					Rect:       title.bboxes,
+					Confidence: title.conf,
+				})
+				charDepth = depth
+				charText.Reset()
+			}
+		case xml.CharData:
+			if charDepth != -1 {
+				charText.Write(t)
+			} else if wordDepth != -1 {
+				wordText.Write(t)
+			}
+		case xml.EndElement:
+			if depth == charDepth {
+				word := &words[len(words)-1]
+				word.Chars[len(word.Chars)-1].Text = strings.TrimSpace(charText.String())
+				charDepth = -1
+			}
+			if depth == wordDepth {
+				word := &words[len(words)-1]
+				word.Text = strings.TrimSpace(wordText.String())
+				if len(word.Chars) > 0 {
+					word.Text = ""
+					for _, char := range word.Chars {
+						word.Text += char.Text
+					}
+				}
+				wordDepth = -1
+			}
+			depth--
+		}
+	}
+
+	return words, nil
+}
+
+// hocrTitle holds the properties of an hOCR title attribute used by the parser.
+type hocrTitle struct {
+	bbox   image.Rectangle
+	wconf  float64
+	bboxes image.Rectangle // Character bounding box
+	conf   float64         // Character confidence
+}
+
+// parseTitle parses an hOCR title attribute such as "bbox 36 92 96 116; x_wconf 96" for a word
+// or "x_bboxes 36 92 48 116; x_conf 99.1" for a character.
+func parseTitle(title string) hocrTitle {
+	var parsed hocrTitle
+	for _, property := range strings.Split(title, ";") {
+		fields := strings.Fields(property)
+		if len(fields) == 0 {
+			continue
+		}
+
+		switch fields[0] {
+		case "bbox":
+			parsed.bbox = parseBbox(fields[1:])
+		case "x_wconf":
+			if len(fields) > 1 {
+				parsed.wconf, _ = strconv.ParseFloat(fields[1], 64)
+			}
+		case "x_bboxes":
+			parsed.bboxes = parseBbox(fields[1:])
+		case "x_conf":
+			if len(fields) > 1 {
+				parsed.conf, _ = strconv.ParseFloat(fields[1], 64)
+			}
+		}
+	}
+
+	return parsed
+}
+
+// parseBbox parses the four coordinates (x0 y0 x1 y1) of an hOCR bounding box.
+func parseBbox(fields []string) image.Rectangle {
+	if len(fields) < 4 {
+		return image.Rectangle{}
+	}
+
+	var coordinates [4]int
+	for i := range coordinates {
+		coordinate, err := strconv.Atoi(fields[i])
+		if err != nil {
+			return image.Rectangle{}
+		}
+		coordinates[i] = coordinate
+	}
+
+	return image.Rect(coordinates[0], coordinates[1], coordinates[2], coordinates[3])
+}
+
+// getAttr returns the value of the attribute with the given name, or an empty string if it is missing.
+func getAttr(element xml.StartElement, name string) string {
+	for _, attr := range element.Attr {
+		if attr.Name.Local == name {
+			return attr.Value
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,147 @@
+package tesseractocr_test
+
+import (
+	"image"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/rogeriofbrito/go-insta-scraper-v2/tesseractocr"
+)
+
+func TestParseHocr_DiverseCases(t *testing.T) {
+	tests := []struct {
+		name      string
+		hocrPath  string
+		hocr      string
+		expected  []tesseractocr.Word
+		expectErr bool
+	}{
+		{
+			name:     "label_column",
+			hocrPath: "testdata/label_column.hocr",
+			expected: []tesseractocr.Word{
+				{Text: "Seguindo", Rect: image.Rect(84, 193, 182, 222), Confidence: 95, Line: 0},
+				{Text: "Enviar", Rect: image.Rect(60, 380, 128, 409), Confidence: 91.5, Line: 1},
+				{Text: "mensagem", Rect: image.Rect(136, 380, 206, 409), Confidence: 88, Line: 1},
+				{Text: "&Seguir'", Rect: image.Rect(100, 566, 166, 595), Confidence: 62, Line: 2},
+			},
+		},
+		{
+			name:     "word_with_char_boxes",
+			hocrPath: "testdata/username_char_boxes.hocr",
+			expected: []tesseractocr.Word{
+				{
+					Text:       "kvraco",
+					Rect:       image.Rect(6, 7, 104, 30),
+					Confidence: 87,
+					Line:       0,
+					Chars: []tesseractocr.Char{
+						{Text: "k", Rect: image.Rect(6, 7, 21, 30), Confidence: 99.2},
+						{Text: "v", Rect: image.Rect(22, 13, 37, 30), Confidence: 98.6},
+						{Text: "r", Rect: image.Rect(39, 13, 49, 30), Confidence: 97.9},
+						{Text: "a", Rect: image.Rect(50, 13, 66, 30), Confidence: 98.8},
+						{Text: "c", Rect: image.Rect(68, 13, 83, 30), Confidence: 41.5},
+						{Text: "o", Rect: image.Rect(85, 13, 104, 30), Confidence: 96.3},
+					},
+				},
+			},
+		},
+		{
+			name: "whitespace_between_char_boxes_ignored",
+			hocr: `<span class='ocr_line' title='bbox 0 0 50 20'>` +
+				`<span class='ocrx_word' title='bbox 1 2 30 20; x_wconf 90'>` + "\n  " +
+				`<span class='ocrx_cinfo' title='x_bboxes 1 2 15 20; x_conf 95'>a</span>` + "\n  " +
+				`<span class='ocrx_cinfo' title='x_bboxes 16 2 30 20; x_conf 85'>_</span>` + "\n" +
+				`</span></span>`,
+			expected: []tesseractocr.Word{
+				{
+					Text:       "a_",
+					Rect:       image.Rect(1, 2, 30, 20),
+					Confidence: 90,
+					Line:       0,
+					Chars: []tesseractocr.Char{
+						{Text: "a", Rect: image.Rect(1, 2, 15, 20), Confidence: 95},
+						{Text: "_", Rect: image.Rect(16, 2, 30, 20), Confidence: 85},
+					},
+				},
+			},
+		},
+		{
+			name:     "empty_page_returns_no_words",
+			hocr:     `<html><body><div class='ocr_page' title='bbox 0 0 10 10'></div></body></html>`,
+			expected: nil,
+		},
+		{
+			name: "word_without_confidence",
+			hocr: `<span class='ocr_line' title='bbox 0 0 50 20'>` +
+				`<span class='ocrx_word' title='bbox 1 2 30 20'>abc</span></span>`,
+			expected: []tesseractocr.Word{
+				{Text: "abc", Rect: image.Rect(1, 2, 30, 20), Confidence: 0, Line: 0},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			hocr := tc.hocr
+			if tc.hocrPath != "" {
+				hocrBytes, err := os.ReadFile(tc.hocrPath)
+				if err != nil {
+					t.Fatalf("failed to read %s: %v", tc.hocrPath, err)
+				}
+				hocr = string(hocrBytes)
+			}
+
+			words, err := tesseractocr.ParseHocr(strings.NewReader(hocr))
+			if tc.expectErr {
+				if err == nil {
+					t.Fatalf("expected error but got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(words) != len(tc.expected) {
+				t.Fatalf("ParseHocr() = %v; expected %v", words, tc.expected)
+			}
+			for i := range words {
+				if !reflect.DeepEqual(words[i], tc.expected[i]) {
+					t.Errorf("ParseHocr()[%d] = %+v; expected %+v", i, words[i], tc.expected[i])
+				}
+			}
+		})
+	}
+}
+
+func TestWord_Add(t *testing.T) {
+	word := tesseractocr.Word{
+		Text:       "a_",
+		Rect:       image.Rect(1, 2, 30, 20),
+		Confidence: 90,
+		Chars: []tesseractocr.Char{
+			{Text: "a", Rect: image.Rect(1, 2, 15, 20), Confidence: 95},
+			{Text: "_", Rect: image.Rect(16, 2, 30, 20), Confidence: 85},
+		},
+	}
+
+	translated := word.Add(image.Pt(165, 518))
+
+	expected := tesseractocr.Word{
+		Text:       "a_",
+		Rect:       image.Rect(166, 520, 195, 538),
+		Confidence: 90,
+		Chars: []tesseractocr.Char{
+			{Text: "a", Rect: image.Rect(166, 520, 180, 538), Confidence: 95},
+			{Text: "_", Rect: image.Rect(181, 520, 195, 538), Confidence: 85},
+		},
+	}
+	if !reflect.DeepEqual(translated, expected) {
+		t.Fatalf("Add() = %+v; expected %+v", translated, expected)
+	}
+	if word.Chars[0].Rect != image.Rect(1, 2, 15, 20) {
+		t.Fatalf("Add() changed the characters of the original word: %+v", word.Chars)
+	}
+}
@@ -1,43 +1,217 @@
 package tesseractocr
 
 import (
+	"bytes"
+	"context"
 	"fmt"
+	"image"
 	"os"
 	"os/exec"
+	"slices"
 	"strconv"
+	"time"
 
+	"github.com/palantir/stacktrace"
 	"github.com/rogeriofbrito/go-insta-scraper-v2/config"
+	"github.com/rogeriofbrito/go-insta-scraper-v2/ocr"
+	"github.com/rogeriofbrito/go-insta-scraper-v2/preprocess"
+	"github.com/rogeriofbrito/go-insta-scraper-v2/util"
+	"gocv.io/x/gocv"
 )
 
+// templateLabelPsm is the page segmentation mode used to find button labels: sparse text, since a column
+// of buttons is not a block of text.
+const templateLabelPsm = 11
+
+// sectionHeaderPsm is the page segmentation mode used to read section headers, which are read one text
+// line at a time.
+const sectionHeaderPsm = 7
+
 func NewTesseractOcr(config *config.Config) *TesseractOcr {
+	return &TesseractOcr{
+		oem:          config.TesseractOcrOem,
+		psm:          config.TesseractOcrPsm,
+		configs:      config.TesseractOcrConfigs,
+		batchPsm:     config.TesseractOcrBatchPsm,
+		batchSpacing: config.TesseractOcrBatchSpacing,
+		timeout:      config.OcrTimeout,
+		preprocessor: preprocess.NewPreprocessor(config),
+	}
+}
+
+// NewDisplayNameTesseractOcr creates a TesseractOcr suited to read display names: it reads them with the
+// TesseractOcrDisplayNameLang language set and without the username character whitelist, which would
+// destroy accented letters and symbols.
+func NewDisplayNameTesseractOcr(config *config.Config) *TesseractOcr {
+	return &TesseractOcr{
+		oem:          config.TesseractOcrOem,
+		psm:          config.TesseractOcrPsm,
+		lang:         config.TesseractOcrDisplayNameLang,
+		configs:      map[string]string{},
+		batchPsm:     config.TesseractOcrBatchPsm,
+		batchSpacing: config.TesseractOcrBatchSpacing,
+		timeout:      config.OcrTimeout,
+	}
+}
+
+// NewTemplateLabelTesseractOcr creates a TesseractOcr suited to find button labels in a column of the
+// screenshot: it looks for sparse text and, unlike usernames, labels are read without a character whitelist.
+func NewTemplateLabelTesseractOcr(config *config.Config) *TesseractOcr {
 	return &TesseractOcr{
 		oem:     config.TesseractOcrOem,
-		psm:     config.TesseractOcrPsm,
-		configs: config.TesseractOcrConfigs,
+		psm:     templateLabelPsm,
+		configs: map[string]string{},
+		timeout: config.OcrTimeout,
+	}
+}
+
+// NewSectionHeaderTesseractOcr creates a TesseractOcr suited to read section headers (e.g. "Suggested for
+// you") from the text lines between rows: it reads a single line with the TesseractOcrDisplayNameLang
+// language set, since headers are written in the app language, and without the username character whitelist.
+func NewSectionHeaderTesseractOcr(config *config.Config) *TesseractOcr {
+	return &TesseractOcr{
+		oem:     config.TesseractOcrOem,
+		psm:     sectionHeaderPsm,
+		lang:    config.TesseractOcrDisplayNameLang,
+		configs: map[string]string{},
+		timeout: config.OcrTimeout,
 	}
 }
 
 type TesseractOcr struct {
-	oem     int
-	psm     int
-	configs map[string]string
+	oem          int
+	psm          int
+	lang         string // Tesseract language set (e.g. por+eng), tesseract default when empty
+	configs      map[string]string
+	batchPsm     int                      // Page segmentation mode used by ReadBatch, which reads several lines at once
+	batchSpacing int                      // Margin added around each region stacked by ReadBatch
+	timeout      time.Duration            // Maximum duration of a tesseract process, no limit when 0
+	preprocessor *preprocess.Preprocessor // Prepares regions before they are read, regions are read as is when nil
+}
+
+// Read implements ocr.Engine: it reads the part of rect inside imageMat through hOCR, so the result
+// carries the word confidences.
+func (t *TesseractOcr) Read(ctx context.Context, imageMat gocv.Mat, rect image.Rectangle) (ocr.Result, error) {
+	words, err := t.ReadWords(ctx, imageMat, rect)
+	if err != nil {
+		return ocr.Result{}, stacktrace.Propagate(err, "failed to read region %v", rect)
+	}
+
+	return ResultFromWords(words), nil
+}
+
+// ReadWords runs Tesseract over the part of rect inside imageMat and returns the recognized words with
+// their bounding boxes, in imageMat coordinates, confidences and characters. The region is preprocessed when
+// the engine has a preprocessor, then piped to Tesseract as a PNG, which is lossless, and the hOCR document
+// read from its stdout, so nothing is written to disk.
+func (t *TesseractOcr) ReadWords(ctx context.Context, imageMat gocv.Mat, rect image.Rectangle) ([]Word, error) {
+	rect, _ = util.ClipRect(rect, image.Rect(0, 0, imageMat.Cols(), imageMat.Rows()))
+	if rect.Empty() {
+		return nil, stacktrace.NewError("failed to read region: rect is outside the image")
+	}
+
+	regionMat, err := t.getRegion(imageMat, rect)
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "failed to get region %v", rect)
+	}
+	defer regionMat.Close()
+
+	regionBuffer, err := gocv.IMEncode(gocv.PNGFileExt, regionMat)
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "failed to encode region %v", rect)
+	}
+	defer regionBuffer.Close()
+
+	hocr, err := t.run(ctx, regionBuffer.GetBytes(), t.psm, "hocr")
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "failed to execute tesseract ocr over region %v", rect)
+	}
+
+	words, err := ParseHocr(bytes.NewReader(hocr))
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "failed to parse hocr of region %v", rect)
+	}
+
+	for i := range words {
+		words[i] = words[i].MapRects(func(wordRect image.Rectangle) image.Rectangle {
+			return t.mapRegionRect(wordRect).Add(rect.Min)
+		})
+	}
+
+	return words, nil
+}
+
+// getRegion returns a copy of rect of imageMat, preprocessed when the engine has a preprocessor.
+// The returned mat must be closed by the caller.
+func (t *TesseractOcr) getRegion(imageMat gocv.Mat, rect image.Rectangle) (gocv.Mat, error) {
+	if t.preprocessor != nil {
+		return t.preprocessor.Apply(imageMat, rect)
+	}
+
+	regionMat := imageMat.Region(rect)
+	defer regionMat.Close()
+
+	return regionMat.Clone(), nil
 }
 
-func (t *TesseractOcr) OCR(imagePath string, resultPath string) error {
+// mapRegionRect maps rect, in the coordinates of a mat returned by getRegion, back to the coordinates of
+// the region it was made from.
+func (t *TesseractOcr) mapRegionRect(rect image.Rectangle) image.Rectangle {
+	if t.preprocessor != nil {
+		return t.preprocessor.MapRect(rect)
+	}
+
+	return rect
+}
+
+// run executes tesseract with the psm page segmentation mode over the encoded image, given on its stdin,
+// and returns what it writes on its stdout.
+// configFiles selects the output format (e.g. "hocr", which is written with character boxes); plain text
+// is written when none is given.
+// The process is killed when ctx is done or when it runs for longer than the timeout.
+func (t *TesseractOcr) run(ctx context.Context, encodedImage []byte, psm int, configFiles ...string) ([]byte, error) {
+	runCtx := ctx
+	if t.timeout > 0 {
+		var cancel context.CancelFunc
+		runCtx, cancel = context.WithTimeout(ctx, t.timeout)
+		defer cancel()
+	}
+
 	args := []string{
-		imagePath,
-		resultPath,
+		"stdin",
+		"stdout",
 		"--oem",
 		strconv.Itoa(t.oem),
 		"--psm",
-		strconv.Itoa(t.psm),
+		strconv.Itoa(psm),
+	}
+	if t.lang != "" {
+		args = append(args, "-l", t.lang)
 	}
 	args = append(args, t.getConfigArgs()...)
+	if slices.Contains(configFiles, "hocr") {
+		args = append(args, "-c", "hocr_char_boxes=1")
+	}
+	args = append(args, configFiles...)
 
-	cmd := exec.Command("tesseract", args...)
+	var stdout bytes.Buffer
+	cmd := exec.CommandContext(runCtx, "tesseract", args...)
+	cmd.Stdin = bytes.NewReader(encodedImage)
+	cmd.Stdout = &stdout
 	cmd.Stderr = os.Stderr
 
-	return cmd.Run()
+	err := cmd.Run()
+	if ctx.Err() != nil {
+		return nil, stacktrace.Propagate(ctx.Err(), "tesseract stopped")
+	}
+	if runCtx.Err() != nil {
+		return nil, stacktrace.Propagate(runCtx.Err(), "tesseract timed out after %v", t.timeout)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return stdout.Bytes(), nil
 }
 
 func (t *TesseractOcr) getConfigArgs() []string {
@@ -0,0 +1,72 @@
+package tesseractocr_test
+
+import (
+	"context"
+	"errors"
+	"image"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/palantir/stacktrace"
+	"github.com/rogeriofbrito/go-insta-scraper-v2/config"
+	"github.com/rogeriofbrito/go-insta-scraper-v2/tesseractocr"
+	"gocv.io/x/gocv"
+)
+
+// TestTesseractOcr_ReadWords_HungProcess replaces tesseract with a process that never answers and checks
+// it is killed by the per-call timeout or by the caller's context.
+func TestTesseractOcr_ReadWords_HungProcess(t *testing.T) {
+	binDirPath := t.TempDir()
+	err := os.WriteFile(filepath.Join(binDirPath, "tesseract"), []byte("#!/bin/sh\nexec sleep 30\n"), 0755)
+	if err != nil {
+		t.Fatalf("error on writing fake tesseract: %v", err)
+	}
+	t.Setenv("PATH", binDirPath+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	tests := []struct {
+		name          string
+		timeout       time.Duration
+		cancelAfter   time.Duration
+		expectedCause error
+	}{
+		{
+			name:          "per_call_timeout",
+			timeout:       200 * time.Millisecond,
+			expectedCause: context.DeadlineExceeded,
+		},
+		{
+			name:          "context_canceled",
+			cancelAfter:   200 * time.Millisecond,
+			expectedCause: context.Canceled,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			imageMat := gocv.NewMatWithSize(36, 440, gocv.MatTypeCV8UC1)
+			defer imageMat.Close()
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			if tc.cancelAfter > 0 {
+				time.AfterFunc(tc.cancelAfter, cancel)
+			}
+
+			tocr := tesseractocr.NewTesseractOcr(&config.Config{TesseractOcrOem: 1, TesseractOcrPsm: 7, OcrTimeout: tc.timeout})
+
+			start := time.Now()
+			_, err := tocr.ReadWords(ctx, imageMat, image.Rect(0, 0, 440, 36))
+			if err == nil {
+				t.Fatalf("expected error but got nil")
+			}
+			if !errors.Is(stacktrace.RootCause(err), tc.expectedCause) {
+				t.Fatalf("ReadWords() error = %v; expected it to be caused by %v", err, tc.expectedCause)
+			}
+			if elapsed := time.Since(start); elapsed > 5*time.Second {
+				t.Fatalf("ReadWords() returned after %v; expected the process to be killed", elapsed)
+			}
+		})
+	}
+}
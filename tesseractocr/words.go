@@ -0,0 +1,106 @@
+package tesseractocr
+
+import (
+	"image"
+	"strings"
+	"unicode"
+
+	"github.com/rogeriofbrito/go-insta-scraper-v2/ocr"
+)
+
+// FindPhrase looks for the first occurrence, in reading order, of phrase among the recognized words.
+// The words of the phrase must be consecutive and on the same text line. Comparison ignores case and
+// any character that is not a letter or a digit, so stray punctuation read by OCR does not prevent a match.
+// Returns the bounding box of the matched words.
+func FindPhrase(words []Word, phrase string) (image.Rectangle, bool) {
+	phraseWords := normalizeWords(strings.Fields(phrase))
+	if len(phraseWords) == 0 {
+		return image.Rectangle{}, false
+	}
+
+	for start := 0; start+len(phraseWords) <= len(words); start++ {
+		rect := image.Rectangle{}
+		matched := true
+		for i, phraseWord := range phraseWords {
+			word := words[start+i]
+			if word.Line != words[start].Line || normalizeWord(word.Text) != phraseWord {
+				matched = false
+				break
+			}
+			rect = rect.Union(word.Rect)
+		}
+
+		if matched {
+			return rect, true
+		}
+	}
+
+	return image.Rectangle{}, false
+}
+
+// normalizeWords normalizes every word, dropping the ones left empty.
+func normalizeWords(words []string) []string {
+	var normalized []string
+	for _, word := range words {
+		if normalizedWord := normalizeWord(word); normalizedWord != "" {
+			normalized = append(normalized, normalizedWord)
+		}
+	}
+
+	return normalized
+}
+
+// normalizeWord lowercases a word and removes every character that is not a letter or a digit.
+func normalizeWord(word string) string {
+	var normalized strings.Builder
+	for _, r := range strings.ToLower(word) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			normalized.WriteRune(r)
+		}
+	}
+
+	return normalized.String()
+}
+
+// ResultFromWords joins the recognized words into an ocr.Result: words of a text line are separated by a
+// space and lines by a newline. The confidence is the mean of the word confidences, 0 when there are no words.
+// Words and their characters are kept, with their own confidences and bounding boxes.
+func ResultFromWords(words []Word) ocr.Result {
+	var text strings.Builder
+	var confidenceSum float64
+	resultWords := make([]ocr.Word, len(words))
+	for i, word := range words {
+		if i > 0 {
+			if word.Line != words[i-1].Line {
+				text.WriteString("\n")
+			} else {
+				text.WriteString(" ")
+			}
+		}
+		text.WriteString(word.Text)
+		confidenceSum += word.Confidence
+
+		resultWords[i] = ocr.Word{
+			Text:       word.Text,
+			Rect:       word.Rect,
+			Confidence: word.Confidence,
+		}
+		for _, char := range word.Chars {
+			resultWords[i].Chars = append(resultWords[i].Chars, ocr.Char{
+				Text:       char.Text,
+				Rect:       char.Rect,
+				Confidence: char.Confidence,
+			})
+		}
+	}
+
+	if len(words) == 0 {
+		return ocr.Result{}
+	}
+
+	return ocr.Result{
+		Text:       text.String(),
+		Confidence: confidenceSum / float64(len(words)),
+		Words:      resultWords,
+	}
+}
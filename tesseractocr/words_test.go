@@ -0,0 +1,168 @@
+package tesseractocr_test
+
+import (
+	"image"
+	"reflect"
+	"testing"
+
+	"github.com/rogeriofbrito/go-insta-scraper-v2/ocr"
+	"github.com/rogeriofbrito/go-insta-scraper-v2/tesseractocr"
+)
+
+func TestFindPhrase_DiverseCases(t *testing.T) {
+	words := []tesseractocr.Word{
+		{Text: "Seguindo", Rect: image.Rect(84, 193, 182, 222), Line: 0},
+		{Text: "Enviar", Rect: image.Rect(60, 380, 128, 409), Line: 1},
+		{Text: "mensagem", Rect: image.Rect(136, 380, 206, 409), Line: 1},
+		{Text: "Seguir'", Rect: image.Rect(100, 566, 166, 595), Line: 2},
+		{Text: "Seguir", Rect: image.Rect(100, 752, 166, 781), Line: 3},
+		{Text: "Sugestões", Rect: image.Rect(10, 900, 120, 930), Line: 4},
+		{Text: "para", Rect: image.Rect(10, 940, 60, 970), Line: 5},
+		{Text: "você", Rect: image.Rect(70, 940, 120, 970), Line: 5},
+	}
+
+	tests := []struct {
+		name          string
+		phrase        string
+		expectedRect  image.Rectangle
+		expectedFound bool
+	}{
+		{
+			name:          "single_word",
+			phrase:        "Seguindo",
+			expectedRect:  image.Rect(84, 193, 182, 222),
+			expectedFound: true,
+		},
+		{
+			name:          "multiple_words_on_same_line",
+			phrase:        "Enviar mensagem",
+			expectedRect:  image.Rect(60, 380, 206, 409),
+			expectedFound: true,
+		},
+		{
+			name:          "case_and_punctuation_ignored_first_occurrence_returned",
+			phrase:        "SEGUIR",
+			expectedRect:  image.Rect(100, 566, 166, 595),
+			expectedFound: true,
+		},
+		{
+			name:          "accented_words",
+			phrase:        "para você",
+			expectedRect:  image.Rect(10, 940, 120, 970),
+			expectedFound: true,
+		},
+		{
+			name:          "words_on_different_lines_do_not_match",
+			phrase:        "Sugestões para você",
+			expectedFound: false,
+		},
+		{
+			name:          "missing_phrase",
+			phrase:        "Remover",
+			expectedFound: false,
+		},
+		{
+			name:          "empty_phrase",
+			phrase:        "  ",
+			expectedFound: false,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			rect, found := tesseractocr.FindPhrase(words, tc.phrase)
+			if found != tc.expectedFound {
+				t.Fatalf("FindPhrase(%q) found = %v; expected %v", tc.phrase, found, tc.expectedFound)
+			}
+			if found && rect != tc.expectedRect {
+				t.Errorf("FindPhrase(%q) = %v; expected %v", tc.phrase, rect, tc.expectedRect)
+			}
+		})
+	}
+}
+
+func TestResultFromWords_DiverseCases(t *testing.T) {
+	tests := []struct {
+		name               string
+		words              []tesseractocr.Word
+		expectedText       string
+		expectedConfidence float64
+		expectedWords      []ocr.Word // Checked only when set
+	}{
+		{
+			name:               "no_words",
+			words:              nil,
+			expectedText:       "",
+			expectedConfidence: 0,
+		},
+		{
+			name: "single_word",
+			words: []tesseractocr.Word{
+				{Text: "stephencurry30", Confidence: 96, Line: 0},
+			},
+			expectedText:       "stephencurry30",
+			expectedConfidence: 96,
+		},
+		{
+			name: "words_on_same_line_joined_by_space",
+			words: []tesseractocr.Word{
+				{Text: "Enviar", Confidence: 90, Line: 0},
+				{Text: "mensagem", Confidence: 80, Line: 0},
+			},
+			expectedText:       "Enviar mensagem",
+			expectedConfidence: 85,
+		},
+		{
+			name: "lines_joined_by_newline",
+			words: []tesseractocr.Word{
+				{Text: "Sugestões", Confidence: 70, Line: 0},
+				{Text: "para", Confidence: 80, Line: 1},
+				{Text: "você", Confidence: 90, Line: 1},
+			},
+			expectedText:       "Sugestões\npara você",
+			expectedConfidence: 80,
+		},
+		{
+			name: "words_and_chars_kept",
+			words: []tesseractocr.Word{
+				{
+					Text:       "kv",
+					Rect:       image.Rect(6, 7, 37, 30),
+					Confidence: 87,
+					Chars: []tesseractocr.Char{
+						{Text: "k", Rect: image.Rect(6, 7, 21, 30), Confidence: 99.2},
+						{Text: "v", Rect: image.Rect(22, 13, 37, 30), Confidence: 41.5},
+					},
+				},
+			},
+			expectedText:       "kv",
+			expectedConfidence: 87,
+			expectedWords: []ocr.Word{
+				{
+					Text:       "kv",
+					Rect:       image.Rect(6, 7, 37, 30),
+					Confidence: 87,
+					Chars: []ocr.Char{
+						{Text: "k", Rect: image.Rect(6, 7, 21, 30), Confidence: 99.2},
+						{Text: "v", Rect: image.Rect(22, 13, 37, 30), Confidence: 41.5},
+					},
+				},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			result := tesseractocr.ResultFromWords(tc.words)
+			if result.Text != tc.expectedText {
+				t.Fatalf("ResultFromWords().Text = %q; expected %q", result.Text, tc.expectedText)
+			}
+			if result.Confidence != tc.expectedConfidence {
+				t.Fatalf("ResultFromWords().Confidence = %v; expected %v", result.Confidence, tc.expectedConfidence)
+			}
+			if tc.expectedWords != nil && !reflect.DeepEqual(result.Words, tc.expectedWords) {
+				t.Fatalf("ResultFromWords().Words = %+v; expected %+v", result.Words, tc.expectedWords)
+			}
+		})
+	}
+}
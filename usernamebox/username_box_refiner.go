@@ -0,0 +1,209 @@
+package usernamebox
+
+import (
+	"image"
+	"sort"
+
+	"github.com/palantir/stacktrace"
+	"github.com/rogeriofbrito/go-insta-scraper-v2/config"
+	"github.com/rogeriofbrito/go-insta-scraper-v2/util"
+	"gocv.io/x/gocv"
+)
+
+// ellipsisDots is the number of dots of the ellipsis that ends truncated usernames. Usernames can not have
+// consecutive periods, so a username ending with this many dots is truncated.
+const ellipsisDots = 3
+
+// dotMaxSizeRatio is the maximum size of a dot, as a fraction of the text height.
+const dotMaxSizeRatio = 0.3
+
+// coloredComponentRatio is the minimum fraction of saturated pixels in the bounding box of a component
+// for it to be a colored symbol (e.g. the verified badge) instead of a character.
+const coloredComponentRatio = 0.3
+
+// Box is the username text found by a UsernameBoxRefiner.
+type Box struct {
+	Rect      image.Rectangle // Bounding box of the username text, without the ellipsis of truncated usernames
+	Truncated bool            // Whether the username ends with an ellipsis or reaches the end of the search area
+}
+
+// NewUsernameBoxRefiner creates a new UsernameBoxRefiner with the text detection parameters of config.
+func NewUsernameBoxRefiner(config *config.Config) *UsernameBoxRefiner {
+	return &UsernameBoxRefiner{
+		config: config,
+	}
+}
+
+// UsernameBoxRefiner shrinks or grows username candidate rects to the extent of the username text,
+// leaving out what is around it, such as the avatar edge and the verified badge.
+type UsernameBoxRefiner struct {
+	config *config.Config
+}
+
+// Refine returns the bounding box of the username that starts inside candidateRect. The text is searched
+// from the left edge of candidateRect up to maxX (e.g. the button left edge), so usernames longer than
+// the candidate are not clipped. The image is thresholded against its background and split into connected
+// components; components touching the left edge of the search area (the avatar edge), noise and, in color
+// images, colored symbols are ignored, and the username is the chain of components that starts at the leftmost
+// one and ends at the first gap wider than UsernameMaxCharGap. The username is truncated when it ends with
+// an ellipsis or when it ends within UsernameTruncationEdgeMargin of the end of the search area, since
+// the rest of it may be hidden. Returns an empty box if no text is found.
+func (r *UsernameBoxRefiner) Refine(imageMat gocv.Mat, candidateRect image.Rectangle, maxX int) (Box, error) {
+	bounds := image.Rect(0, 0, imageMat.Cols(), imageMat.Rows())
+	searchRect := image.Rect(candidateRect.Min.X, candidateRect.Min.Y, max(candidateRect.Max.X, maxX), candidateRect.Max.Y).
+		Intersect(bounds)
+	if searchRect.Empty() {
+		return Box{}, nil
+	}
+
+	components, err := r.getComponents(imageMat, searchRect)
+	if err != nil {
+		return Box{}, stacktrace.Propagate(err, "failed to get text components of %v", searchRect)
+	}
+	if len(components) == 0 {
+		return Box{}, nil
+	}
+
+	sort.Slice(components, func(i, j int) bool {
+		return components[i].Min.X < components[j].Min.X
+	})
+
+	chain := []image.Rectangle{components[0]}
+	textRect := components[0]
+	for _, component := range components[1:] {
+		if component.Min.X-textRect.Max.X > r.config.UsernameMaxCharGap {
+			break
+		}
+		chain = append(chain, component)
+		textRect = textRect.Union(component)
+	}
+
+	reachesEdge := searchRect.Dx()-textRect.Max.X <= r.config.UsernameTruncationEdgeMargin
+	if hasEllipsis(chain, textRect) {
+		textRect = chain[0]
+		for _, component := range chain[1 : len(chain)-ellipsisDots] {
+			textRect = textRect.Union(component)
+		}
+		return Box{Rect: textRect.Add(searchRect.Min), Truncated: true}, nil
+	}
+
+	return Box{Rect: textRect.Add(searchRect.Min), Truncated: reachesEdge}, nil
+}
+
+// hasEllipsis returns whether the chain of components of the text at textRect ends with an ellipsis:
+// dots, small and in the lower half of the text, following at least one other character.
+func hasEllipsis(chain []image.Rectangle, textRect image.Rectangle) bool {
+	if len(chain) <= ellipsisDots {
+		return false
+	}
+
+	maxDotSize := int(dotMaxSizeRatio * float64(textRect.Dy()))
+	middleY := (textRect.Min.Y + textRect.Max.Y) / 2
+	for _, component := range chain[len(chain)-ellipsisDots:] {
+		isDot := component.Dx() <= maxDotSize && component.Dy() <= maxDotSize &&
+			component.Dx() <= 2*component.Dy() && component.Dy() <= 2*component.Dx() &&
+			component.Min.Y >= middleY
+		if !isDot {
+			return false
+		}
+	}
+
+	return true
+}
+
+// getComponents returns the bounding boxes, relative to searchRect, of the text components inside it.
+func (r *UsernameBoxRefiner) getComponents(imageMat gocv.Mat, searchRect image.Rectangle) ([]image.Rectangle, error) {
+	inkMat, err := r.getInkMask(imageMat, searchRect)
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "failed to get ink mask")
+	}
+	defer inkMat.Close()
+
+	saturationMat, err := r.getSaturationMask(imageMat, searchRect)
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "failed to get saturation mask")
+	}
+	defer saturationMat.Close()
+
+	labelsMat := gocv.NewMat()
+	defer labelsMat.Close()
+	statsMat := gocv.NewMat()
+	defer statsMat.Close()
+	centroidsMat := gocv.NewMat()
+	defer centroidsMat.Close()
+
+	count := gocv.ConnectedComponentsWithStats(inkMat, &labelsMat, &statsMat, &centroidsMat)
+
+	var components []image.Rectangle
+	// Label 0 is the background
+	for label := 1; label < count; label++ {
+		left := int(statsMat.GetIntAt(label, int(gocv.CC_STAT_LEFT)))
+		top := int(statsMat.GetIntAt(label, int(gocv.CC_STAT_TOP)))
+		width := int(statsMat.GetIntAt(label, int(gocv.CC_STAT_WIDTH)))
+		height := int(statsMat.GetIntAt(label, int(gocv.CC_STAT_HEIGHT)))
+		area := int(statsMat.GetIntAt(label, int(gocv.CC_STAT_AREA)))
+		component := image.Rect(left, top, left+width, top+height)
+
+		if area < r.config.UsernameMinComponentArea || component.Min.X == 0 {
+			continue
+		}
+		if !saturationMat.Empty() && isColored(saturationMat, component) {
+			continue
+		}
+
+		components = append(components, component)
+	}
+
+	return components, nil
+}
+
+// getInkMask returns a binary mask of the searchRect region where pixels whose gray value differs from
+// the background by more than UsernameInkThreshold are set.
+func (r *UsernameBoxRefiner) getInkMask(imageMat gocv.Mat, searchRect image.Rectangle) (gocv.Mat, error) {
+	background, err := util.GetBackgroundLevel(imageMat, searchRect)
+	if err != nil {
+		return gocv.Mat{}, stacktrace.Propagate(err, "failed to get background level")
+	}
+
+	grayMat, err := util.GetGrayRegion(imageMat, searchRect)
+	if err != nil {
+		return gocv.Mat{}, stacktrace.Propagate(err, "failed to get gray region")
+	}
+	defer grayMat.Close()
+
+	backgroundMat := gocv.NewMatWithSizeFromScalar(gocv.NewScalar(float64(background), 0, 0, 0), grayMat.Rows(), grayMat.Cols(), gocv.MatTypeCV8U)
+	defer backgroundMat.Close()
+
+	inkMat := gocv.NewMat()
+	err = gocv.AbsDiff(grayMat, backgroundMat, &inkMat)
+	if err != nil {
+		inkMat.Close()
+		return gocv.Mat{}, stacktrace.Propagate(err, "failed to subtract background")
+	}
+	gocv.Threshold(inkMat, &inkMat, float32(r.config.UsernameInkThreshold), 255, gocv.ThresholdBinary)
+
+	return inkMat, nil
+}
+
+// getSaturationMask returns a binary mask of the searchRect region where pixels with a saturation above
+// BadgeMinSaturation are set. The mask is empty for grayscale images.
+func (r *UsernameBoxRefiner) getSaturationMask(imageMat gocv.Mat, searchRect image.Rectangle) (gocv.Mat, error) {
+	if imageMat.Channels() != 3 {
+		return gocv.NewMat(), nil
+	}
+
+	saturationMat, err := util.GetSaturationRegion(imageMat, searchRect)
+	if err != nil {
+		return gocv.Mat{}, stacktrace.Propagate(err, "failed to get saturation region")
+	}
+	gocv.Threshold(saturationMat, &saturationMat, float32(r.config.BadgeMinSaturation), 255, gocv.ThresholdBinary)
+
+	return saturationMat, nil
+}
+
+func isColored(saturationMat gocv.Mat, component image.Rectangle) bool {
+	componentMat := saturationMat.Region(component)
+	defer componentMat.Close()
+
+	return float64(gocv.CountNonZero(componentMat)) >= coloredComponentRatio*float64(component.Dx()*component.Dy())
+}
@@ -0,0 +1,157 @@
+package usernamebox_test
+
+import (
+	"image"
+	"testing"
+
+	"github.com/rogeriofbrito/go-insta-scraper-v2/config"
+	"github.com/rogeriofbrito/go-insta-scraper-v2/usernamebox"
+	"gocv.io/x/gocv"
+)
+
+func TestUsernameBoxRefiner_Refine_DiverseCases(t *testing.T) {
+	// Allowed distance between expected and found edges, to absorb anti-aliasing
+	const tolerance = 1
+
+	tests := []struct {
+		name              string
+		imagePath         string
+		flags             gocv.IMReadFlag
+		candidateRect     image.Rectangle
+		maxX              int
+		maxCharGap        int
+		expectedRect      image.Rectangle
+		expectedTruncated bool
+	}{
+		{
+			name:          "badge_is_left_out",
+			imagePath:     "testdata/badge_row.png",
+			flags:         gocv.IMReadColor,
+			candidateRect: image.Rect(165, 9, 605, 45),
+			maxX:          629,
+			maxCharGap:    5,
+			expectedRect:  image.Rect(176, 14, 392, 42),
+		},
+		{
+			name:          "badge_is_left_out_grayscale",
+			imagePath:     "testdata/badge_row.png",
+			flags:         gocv.IMReadGrayScale,
+			candidateRect: image.Rect(165, 9, 605, 45),
+			maxX:          629,
+			maxCharGap:    5,
+			expectedRect:  image.Rect(176, 14, 392, 42),
+		},
+		{
+			name:          "colored_badge_is_left_out_even_with_wide_gap",
+			imagePath:     "testdata/badge_row.png",
+			flags:         gocv.IMReadColor,
+			candidateRect: image.Rect(165, 9, 605, 45),
+			maxX:          629,
+			maxCharGap:    20,
+			expectedRect:  image.Rect(176, 14, 392, 42),
+		},
+		{
+			name:          "gray_badge_is_kept_with_wide_gap",
+			imagePath:     "testdata/badge_row.png",
+			flags:         gocv.IMReadGrayScale,
+			candidateRect: image.Rect(165, 9, 605, 45),
+			maxX:          629,
+			maxCharGap:    20,
+			expectedRect:  image.Rect(176, 13, 430, 42),
+		},
+		{
+			name:          "narrow_candidate_grows_to_text_end",
+			imagePath:     "testdata/badge_row.png",
+			flags:         gocv.IMReadColor,
+			candidateRect: image.Rect(165, 9, 300, 45),
+			maxX:          629,
+			maxCharGap:    5,
+			expectedRect:  image.Rect(176, 14, 392, 42),
+		},
+		{
+			name:          "long_username",
+			imagePath:     "testdata/long_username_row.png",
+			flags:         gocv.IMReadColor,
+			candidateRect: image.Rect(165, 6, 605, 42),
+			maxX:          629,
+			maxCharGap:    5,
+			expectedRect:  image.Rect(176, 10, 452, 39),
+		},
+		{
+			name:              "ellipsis_is_left_out_and_marks_truncated",
+			imagePath:         "testdata/ellipsis_row.png",
+			flags:             gocv.IMReadColor,
+			candidateRect:     image.Rect(10, 2, 200, 38),
+			maxX:              290,
+			maxCharGap:        5,
+			expectedRect:      image.Rect(20, 8, 147, 30),
+			expectedTruncated: true,
+		},
+		{
+			name:              "text_reaching_search_end_marks_truncated",
+			imagePath:         "testdata/long_username_row.png",
+			flags:             gocv.IMReadColor,
+			candidateRect:     image.Rect(165, 6, 440, 42),
+			maxX:              440,
+			maxCharGap:        5,
+			expectedRect:      image.Rect(176, 10, 440, 39),
+			expectedTruncated: true,
+		},
+		{
+			name:          "blank_region_returns_empty_rect",
+			imagePath:     "testdata/blank_row.png",
+			flags:         gocv.IMReadColor,
+			candidateRect: image.Rect(165, 10, 605, 60),
+			maxX:          629,
+			maxCharGap:    5,
+			expectedRect:  image.Rectangle{},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			imageMat := gocv.IMRead(tc.imagePath, tc.flags)
+			if imageMat.Empty() {
+				t.Fatalf("failed to load image: %s", tc.imagePath)
+			}
+			defer imageMat.Close()
+
+			cfg := config.Config{
+				UsernameInkThreshold:         40,
+				UsernameMinComponentArea:     4,
+				UsernameMaxCharGap:           tc.maxCharGap,
+				UsernameTruncationEdgeMargin: 3,
+				BadgeMinSaturation:           100,
+			}
+			r := usernamebox.NewUsernameBoxRefiner(&cfg)
+
+			box, err := r.Refine(imageMat, tc.candidateRect, tc.maxX)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			rect := box.Rect
+			if box.Truncated != tc.expectedTruncated {
+				t.Errorf("Refine(%v) truncated = %v; expected %v", tc.candidateRect, box.Truncated, tc.expectedTruncated)
+			}
+
+			if tc.expectedRect.Empty() {
+				if !rect.Empty() {
+					t.Errorf("Refine(%v) = %v; expected empty rect", tc.candidateRect, rect)
+				}
+				return
+			}
+			if abs(rect.Min.X-tc.expectedRect.Min.X) > tolerance || abs(rect.Min.Y-tc.expectedRect.Min.Y) > tolerance ||
+				abs(rect.Max.X-tc.expectedRect.Max.X) > tolerance || abs(rect.Max.Y-tc.expectedRect.Max.Y) > tolerance {
+				t.Errorf("Refine(%v) = %v; expected %v", tc.candidateRect, rect, tc.expectedRect)
+			}
+		})
+	}
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,199 @@
+package usernamegrammar
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// MaxLength is the maximum number of characters of an Instagram username.
+const MaxLength = 30
+
+// Status is the outcome of validating a username read by OCR.
+type Status int
+
+const (
+	StatusUnchecked Status = iota // Username was not validated (e.g. validation is disabled or nothing was read)
+	StatusValid                   // Raw read follows the username grammar and is kept as is
+	StatusCorrected               // Raw read breaks the username grammar, a correction of it follows it
+	StatusInvalid                 // Neither the raw read nor any correction follows the username grammar
+)
+
+func (s Status) String() string {
+	switch s {
+	case StatusUnchecked:
+		return "unchecked"
+	case StatusValid:
+		return "valid"
+	case StatusCorrected:
+		return "corrected"
+	case StatusInvalid:
+		return "invalid"
+	default:
+		return fmt.Sprintf("Status(%d)", int(s))
+	}
+}
+
+// Validation is the result of validating a username read by OCR.
+type Validation struct {
+	Username string // Username to use: the raw read when valid, its correction when corrected, empty when invalid
+	Raw      string // Username as read by OCR
+	Status   Status // Whether the raw read was valid, corrected or invalid
+	Problem  string // Grammar rule the raw read breaks, empty when valid
+}
+
+// confusions maps characters outside the username alphabet to the characters they are most likely a misread
+// of, or to nothing for noise. Lowercase letters, digits, dots and underscores are never rewritten, since the
+// grammar can not tell a misread l from a 1 or an o from a 0. Tesseract never outputs them while
+// TesseractOcrConfigs whitelists the username alphabet, as the default config does, so they only apply to
+// reads made with the whitelist off. Spaces are not in the map: see replaceConfusions.
+var confusions = map[rune]string{
+	'I':  "l",
+	'|':  "l",
+	'!':  "i",
+	'¡':  "i",
+	'í':  "i",
+	'ì':  "i",
+	'O':  "o",
+	'Q':  "o",
+	'ó':  "o",
+	'ò':  "o",
+	'°':  "o",
+	'-':  "_",
+	'—':  "_",
+	',':  ".",
+	'·':  ".",
+	'\'': "",
+	'"':  "",
+	'`':  "",
+}
+
+// Validate checks raw, a username read by OCR, against the Instagram username grammar: at most MaxLength
+// characters of [a-z0-9._], without consecutive dots and without a dot at the start or at the end. When
+// prefix is set raw is only the beginning of the username (e.g. it is truncated), so it may end with a dot.
+// An invalid raw read is corrected by applying, one after the other, the fixes for the usual OCR confusions:
+// lookalike characters outside the alphabet (e.g. I or | for l, O for o) and spaces at the edges, stray dots at the edges or next to
+// each other and, for reads that are too long, rn read for m and stray underscores at the edges. Under the
+// username whitelist only the last three can apply. The first correction that follows the grammar is
+// returned; corrections never touch a valid read.
+func Validate(raw string, prefix bool) Validation {
+	validation := Validation{Raw: raw}
+	validation.Problem = check(raw, prefix)
+	if validation.Problem == "" {
+		validation.Username = raw
+		validation.Status = StatusValid
+		return validation
+	}
+
+	corrected := raw
+	for _, correct := range corrections {
+		candidate := correct(corrected, prefix)
+		if candidate == corrected {
+			continue
+		}
+		corrected = candidate
+		if check(corrected, prefix) == "" {
+			validation.Username = corrected
+			validation.Status = StatusCorrected
+			return validation
+		}
+	}
+
+	validation.Status = StatusInvalid
+	return validation
+}
+
+// check returns the first grammar rule username breaks, empty if it breaks none.
+func check(username string, prefix bool) string {
+	switch {
+	case username == "":
+		return "username is empty"
+	case utf8.RuneCountInString(username) > MaxLength:
+		return fmt.Sprintf("username has %d characters, more than %d", utf8.RuneCountInString(username), MaxLength)
+	}
+
+	for _, r := range username {
+		if !inAlphabet(r) {
+			return fmt.Sprintf("username has character %q, outside [a-z0-9._]", r)
+		}
+	}
+
+	switch {
+	case strings.HasPrefix(username, "."):
+		return "username starts with a dot"
+	case strings.Contains(username, ".."):
+		return "username has consecutive dots"
+	case !prefix && strings.HasSuffix(username, "."):
+		return "username ends with a dot"
+	}
+
+	return ""
+}
+
+func inAlphabet(r rune) bool {
+	return (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') || r == '.' || r == '_'
+}
+
+// corrections are the fixes Validate tries, most likely first. Each one gets the result of the previous ones.
+var corrections = []func(username string, prefix bool) string{
+	replaceConfusions,
+	fixDots,
+	shortenM,
+	trimUnderscores,
+}
+
+// replaceConfusions replaces the characters outside the alphabet by the characters they are most likely
+// a misread of: lookalikes by their confusion, other letters by their lowercase and the rest is dropped.
+// Spaces at the edges are dropped too, but spaces between characters are kept, so the read stays invalid:
+// a username never has one, so they come from a display name spilling into the username rect (e.g.
+// "john smith"), and no correction of it is the username.
+func replaceConfusions(username string, _ bool) string {
+	var corrected strings.Builder
+	for _, r := range strings.TrimSpace(username) {
+		switch replacement, ok := confusions[r]; {
+		case inAlphabet(r), r == ' ':
+			corrected.WriteRune(r)
+		case ok:
+			corrected.WriteString(replacement)
+		case inAlphabet(unicode.ToLower(r)):
+			corrected.WriteRune(unicode.ToLower(r))
+		}
+	}
+
+	return corrected.String()
+}
+
+// fixDots collapses consecutive dots, which OCR reads from a single dot or from specks, and drops the dots
+// at the edges, since a username can not start or end with one.
+func fixDots(username string, prefix bool) string {
+	for strings.Contains(username, "..") {
+		username = strings.ReplaceAll(username, "..", ".")
+	}
+	username = strings.TrimLeft(username, ".")
+	if !prefix {
+		username = strings.TrimRight(username, ".")
+	}
+
+	return username
+}
+
+// shortenM replaces "rn" by the "m" it was most likely read from, as many times as needed for a username
+// that is too long to fit.
+func shortenM(username string, _ bool) string {
+	for utf8.RuneCountInString(username) > MaxLength && strings.Contains(username, "rn") {
+		username = strings.Replace(username, "rn", "m", 1)
+	}
+
+	return username
+}
+
+// trimUnderscores drops the underscores at the edges of a username that is too long to fit, which OCR reads
+// from specks or from the underline of a highlighted row.
+func trimUnderscores(username string, _ bool) string {
+	if utf8.RuneCountInString(username) <= MaxLength {
+		return username
+	}
+
+	return strings.Trim(username, "_")
+}
@@ -0,0 +1,180 @@
+package usernamegrammar_test
+
+import (
+	"testing"
+
+	"github.com/rogeriofbrito/go-insta-scraper-v2/usernamegrammar"
+)
+
+func TestValidate_DiverseCases(t *testing.T) {
+	tests := []struct {
+		name             string
+		raw              string
+		prefix           bool
+		expectedUsername string
+		expectedStatus   usernamegrammar.Status
+		expectedProblem  string // Unchecked when empty
+	}{
+		{
+			name:             "valid_username",
+			raw:              "kvraco",
+			expectedUsername: "kvraco",
+			expectedStatus:   usernamegrammar.StatusValid,
+		},
+		{
+			name:             "valid_username_with_dots_and_underscores",
+			raw:              "_jo.silva_99",
+			expectedUsername: "_jo.silva_99",
+			expectedStatus:   usernamegrammar.StatusValid,
+		},
+		{
+			name:             "valid_username_at_max_length",
+			raw:              "abcdefghijklmnopqrstuvwxyz0123",
+			expectedUsername: "abcdefghijklmnopqrstuvwxyz0123",
+			expectedStatus:   usernamegrammar.StatusValid,
+		},
+		{
+			// The grammar can not tell a 1 from an l, so valid reads are never rewritten
+			name:             "in_alphabet_confusions_are_kept",
+			raw:              "he1lo0",
+			expectedUsername: "he1lo0",
+			expectedStatus:   usernamegrammar.StatusValid,
+		},
+		{
+			name:             "prefix_may_end_with_a_dot",
+			raw:              "maria.",
+			prefix:           true,
+			expectedUsername: "maria.",
+			expectedStatus:   usernamegrammar.StatusValid,
+		},
+		// Characters outside the alphabet are only read with the username whitelist off
+		{
+			name:             "uppercase_i_and_pipe_are_l",
+			raw:              "Iuca|ima",
+			expectedUsername: "lucalima",
+			expectedStatus:   usernamegrammar.StatusCorrected,
+		},
+		{
+			name:             "uppercase_o_is_o",
+			raw:              "jOao",
+			expectedUsername: "joao",
+			expectedStatus:   usernamegrammar.StatusCorrected,
+		},
+		{
+			name:             "other_uppercase_letters_are_lowered",
+			raw:              "Maria",
+			expectedUsername: "maria",
+			expectedStatus:   usernamegrammar.StatusCorrected,
+		},
+		{
+			name:             "edge_spaces_are_dropped",
+			raw:              " anasouza ",
+			expectedUsername: "anasouza",
+			expectedStatus:   usernamegrammar.StatusCorrected,
+		},
+		{
+			// A display name spilling into the username rect is not turned into a username
+			name:             "inner_space_is_invalid",
+			raw:              "ana souza",
+			expectedUsername: "",
+			expectedStatus:   usernamegrammar.StatusInvalid,
+		},
+		{
+			// 30 characters but 32 bytes, so only the accents break the grammar
+			name:             "accented_read_at_max_length_counted_in_characters",
+			raw:              "abcdefghíjklmnopqrstuvwxyz012ó",
+			expectedUsername: "abcdefghijklmnopqrstuvwxyz012o",
+			expectedStatus:   usernamegrammar.StatusCorrected,
+			expectedProblem:  "username has character 'í', outside [a-z0-9._]",
+		},
+		{
+			name:             "stray_quote_is_dropped",
+			raw:              "ana'souza",
+			expectedUsername: "anasouza",
+			expectedStatus:   usernamegrammar.StatusCorrected,
+		},
+		// Reads the default username whitelist allows
+		{
+			name:             "consecutive_dots_are_collapsed",
+			raw:              "ana..souza",
+			expectedUsername: "ana.souza",
+			expectedStatus:   usernamegrammar.StatusCorrected,
+		},
+		{
+			name:             "leading_and_trailing_dots_are_dropped",
+			raw:              ".anasouza.",
+			expectedUsername: "anasouza",
+			expectedStatus:   usernamegrammar.StatusCorrected,
+		},
+		{
+			name:             "trailing_dot_of_prefix_is_kept",
+			raw:              ".anasouza.",
+			prefix:           true,
+			expectedUsername: "anasouza.",
+			expectedStatus:   usernamegrammar.StatusCorrected,
+		},
+		{
+			name:             "rn_is_m_when_too_long",
+			raw:              "thernarvelousrnarnrnaofthenorth",
+			expectedUsername: "themarvelousrnarnrnaofthenorth",
+			expectedStatus:   usernamegrammar.StatusCorrected,
+		},
+		{
+			name:             "dots_then_rn_fixed_when_too_long",
+			raw:              ".thernarvelous..rnariarnagalhaes_.",
+			expectedUsername: "themarvelous.rnariarnagalhaes_",
+			expectedStatus:   usernamegrammar.StatusCorrected,
+		},
+		{
+			name:             "rn_is_kept_when_it_fits",
+			raw:              "barnabe.",
+			expectedUsername: "barnabe",
+			expectedStatus:   usernamegrammar.StatusCorrected,
+		},
+		{
+			name:             "edge_underscores_are_dropped_when_too_long",
+			raw:              "_abcdefghijklmnopqrstuvwxyz012_",
+			expectedUsername: "abcdefghijklmnopqrstuvwxyz012",
+			expectedStatus:   usernamegrammar.StatusCorrected,
+		},
+		{
+			name:             "empty_read_is_invalid",
+			raw:              "",
+			expectedUsername: "",
+			expectedStatus:   usernamegrammar.StatusInvalid,
+		},
+		{
+			name:             "read_of_only_dots_is_invalid",
+			raw:              "...",
+			expectedUsername: "",
+			expectedStatus:   usernamegrammar.StatusInvalid,
+		},
+		{
+			name:             "too_long_without_corrections_is_invalid",
+			raw:              "abcdefghijklmnopqrstuvwxyz0123456789",
+			expectedUsername: "",
+			expectedStatus:   usernamegrammar.StatusInvalid,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			validation := usernamegrammar.Validate(tt.raw, tt.prefix)
+			if validation.Username != tt.expectedUsername {
+				t.Errorf("Validate(%q).Username = %q, expected %q", tt.raw, validation.Username, tt.expectedUsername)
+			}
+			if validation.Status != tt.expectedStatus {
+				t.Errorf("Validate(%q).Status = %v, expected %v", tt.raw, validation.Status, tt.expectedStatus)
+			}
+			if validation.Raw != tt.raw {
+				t.Errorf("Validate(%q).Raw = %q, expected the raw read", tt.raw, validation.Raw)
+			}
+			if (validation.Problem == "") != (tt.expectedStatus == usernamegrammar.StatusValid) {
+				t.Errorf("Validate(%q).Problem = %q, expected a problem only for invalid reads", tt.raw, validation.Problem)
+			}
+			if tt.expectedProblem != "" && validation.Problem != tt.expectedProblem {
+				t.Errorf("Validate(%q).Problem = %q, expected %q", tt.raw, validation.Problem, tt.expectedProblem)
+			}
+		})
+	}
+}
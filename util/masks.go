@@ -0,0 +1,133 @@
+package util
+
+import (
+	"image"
+	"math"
+
+	"github.com/palantir/stacktrace"
+	"gocv.io/x/gocv"
+)
+
+// SplitAlphaMask separates an image read with gocv.IMReadUnchanged into its color
+// channels and its alpha channel, so the alpha channel can be used as a matching mask.
+// imageMat: the input image as a gocv.Mat (BGRA, BGR or grayscale)
+// Returns the color image (BGR, or the grayscale image unchanged) and a single-channel mask
+// where pixels less than half opaque are zero and every other pixel is 255. If the image has no alpha channel, or all of its pixels
+// are fully opaque, the returned mask is empty, which gocv.MatchTemplate treats as "no mask".
+// The caller owns both returned Mats and must close them.
+func SplitAlphaMask(imageMat gocv.Mat) (gocv.Mat, gocv.Mat, error) {
+	// Images without an alpha channel are returned as they are, with an empty mask.
+	if imageMat.Channels() != 4 {
+		return imageMat.Clone(), gocv.NewMat(), nil
+	}
+
+	channels := gocv.Split(imageMat)
+	defer func() {
+		for _, channel := range channels[:3] {
+			channel.Close()
+		}
+	}()
+
+	colorMat := gocv.NewMat()
+	err := gocv.Merge(channels[:3], &colorMat)
+	if err != nil {
+		colorMat.Close()
+		channels[3].Close()
+		return gocv.Mat{}, gocv.Mat{}, stacktrace.Propagate(err, "failed to merge color channels")
+	}
+
+	// A fully opaque alpha channel would only slow matching down without changing the result.
+	maskMat := channels[3]
+	minAlpha, _, _, _ := gocv.MinMaxLoc(maskMat)
+	if minAlpha == 255 {
+		maskMat.Close()
+		return colorMat, gocv.NewMat(), nil
+	}
+
+	// MatchTemplate weighs each pixel by its mask value, so the anti-aliased edges of a template would
+	// count partially. Binarize the alpha channel so every pixel is either matched or ignored.
+	binaryMaskMat := gocv.NewMat()
+	gocv.Threshold(maskMat, &binaryMaskMat, 127, 255, gocv.ThresholdBinary)
+	maskMat.Close()
+
+	return colorMat, binaryMaskMat, nil
+}
+
+// GetCornerBackgroundMask builds a mask that removes the screen background visible at the corners of
+// a cropped button, e.g. around its rounded corners. Starting at each corner, it flood fills the pixels
+// whose channels differ from the corner pixel by at most threshold. Corners whose pixel is similar to
+// the pixel at the middle of the left edge (the button fill) are left untouched, so a crop without
+// visible background keeps every pixel.
+// imageMat: the cropped button as a gocv.Mat (grayscale or BGR)
+// threshold: the maximum allowed difference between pixel values
+// Returns a single-channel mask where background pixels are 0 and every other pixel is 255.
+// The caller owns the returned Mat and must close it.
+func GetCornerBackgroundMask(imageMat gocv.Mat, threshold int) (gocv.Mat, error) {
+	if imageMat.Empty() {
+		return gocv.Mat{}, stacktrace.NewError("failed to build corner background mask: image empty")
+	}
+
+	// Regions of a bigger image are not continuous in memory, so work over a copy.
+	continuousMat := imageMat.Clone()
+	defer continuousMat.Close()
+
+	width, height, channels := continuousMat.Cols(), continuousMat.Rows(), continuousMat.Channels()
+	pixels := continuousMat.ToBytes()
+	pixelAt := func(x, y int) []byte {
+		offset := (y*width + x) * channels
+		return pixels[offset : offset+channels]
+	}
+	similar := func(a, b []byte) bool {
+		for c := range a {
+			if math.Abs(float64(a[c])-float64(b[c])) > float64(threshold) {
+				return false
+			}
+		}
+		return true
+	}
+
+	mask := make([]byte, width*height)
+	for i := range mask {
+		mask[i] = 255
+	}
+
+	fill := pixelAt(min(2, width-1), height/2)
+	corners := []image.Point{{0, 0}, {width - 1, 0}, {0, height - 1}, {width - 1, height - 1}}
+	for _, corner := range corners {
+		seed := pixelAt(corner.X, corner.Y)
+		if similar(seed, fill) || mask[corner.Y*width+corner.X] == 0 {
+			continue
+		}
+
+		// Breadth-first flood fill over the pixels similar to the corner pixel
+		queue := []image.Point{corner}
+		mask[corner.Y*width+corner.X] = 0
+		for len(queue) > 0 {
+			point := queue[0]
+			queue = queue[1:]
+
+			neighbors := []image.Point{
+				{point.X - 1, point.Y}, {point.X + 1, point.Y},
+				{point.X, point.Y - 1}, {point.X, point.Y + 1},
+			}
+			for _, neighbor := range neighbors {
+				if neighbor.X < 0 || neighbor.X >= width || neighbor.Y < 0 || neighbor.Y >= height {
+					continue
+				}
+				if mask[neighbor.Y*width+neighbor.X] == 0 || !similar(pixelAt(neighbor.X, neighbor.Y), seed) {
+					continue
+				}
+
+				mask[neighbor.Y*width+neighbor.X] = 0
+				queue = append(queue, neighbor)
+			}
+		}
+	}
+
+	maskMat, err := gocv.NewMatFromBytes(height, width, gocv.MatTypeCV8UC1, mask)
+	if err != nil {
+		return gocv.Mat{}, stacktrace.Propagate(err, "failed to create mask mat")
+	}
+
+	return maskMat, nil
+}
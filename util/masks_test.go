@@ -0,0 +1,144 @@
+package util_test
+
+import (
+	"testing"
+
+	"gocv.io/x/gocv"
+
+	"github.com/rogeriofbrito/go-insta-scraper-v2/util"
+)
+
+func TestSplitAlphaMask_DiverseCases(t *testing.T) {
+	tests := []struct {
+		name              string
+		imagePath         string
+		expectedChannels  int
+		expectedMaskEmpty bool
+		expectedMaskZeros int // number of transparent pixels in the mask
+	}{
+		{
+			name:              "transparent_corners_returns_mask",
+			imagePath:         "testdata/masks/transparent_corners.png",
+			expectedChannels:  3,
+			expectedMaskEmpty: false,
+			expectedMaskZeros: 4 * 4 * 4,
+		},
+		{
+			// Edge pixels less than half opaque are left out, the rest are fully in the mask
+			name:              "semi_transparent_edges_are_binarized",
+			imagePath:         "testdata/masks/semi_transparent_edges.png",
+			expectedChannels:  3,
+			expectedMaskEmpty: false,
+			expectedMaskZeros: 4*4*4 + 8,
+		},
+		{
+			name:              "opaque_alpha_returns_empty_mask",
+			imagePath:         "testdata/masks/opaque_alpha.png",
+			expectedChannels:  3,
+			expectedMaskEmpty: true,
+		},
+		{
+			name:              "no_alpha_returns_image_and_empty_mask",
+			imagePath:         "testdata/masks/no_alpha.png",
+			expectedChannels:  1,
+			expectedMaskEmpty: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			imageMat := gocv.IMRead(tc.imagePath, gocv.IMReadUnchanged)
+			if imageMat.Empty() {
+				t.Fatalf("failed to load image: %s", tc.imagePath)
+			}
+			defer imageMat.Close()
+
+			colorMat, maskMat, err := util.SplitAlphaMask(imageMat)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			defer colorMat.Close()
+			defer maskMat.Close()
+
+			if colorMat.Channels() != tc.expectedChannels {
+				t.Errorf("SplitAlphaMask(%s) color channels = %d; expected %d", tc.imagePath, colorMat.Channels(), tc.expectedChannels)
+			}
+			if colorMat.Rows() != imageMat.Rows() || colorMat.Cols() != imageMat.Cols() {
+				t.Errorf("SplitAlphaMask(%s) color size = %dx%d; expected %dx%d",
+					tc.imagePath, colorMat.Cols(), colorMat.Rows(), imageMat.Cols(), imageMat.Rows())
+			}
+			if maskMat.Empty() != tc.expectedMaskEmpty {
+				t.Fatalf("SplitAlphaMask(%s) mask empty = %v; expected %v", tc.imagePath, maskMat.Empty(), tc.expectedMaskEmpty)
+			}
+			if !tc.expectedMaskEmpty {
+				zeros := maskMat.Rows()*maskMat.Cols() - gocv.CountNonZero(maskMat)
+				if zeros != tc.expectedMaskZeros {
+					t.Errorf("SplitAlphaMask(%s) transparent pixels = %d; expected %d", tc.imagePath, zeros, tc.expectedMaskZeros)
+				}
+				for _, value := range maskMat.ToBytes() {
+					if value != 0 && value != 255 {
+						t.Errorf("SplitAlphaMask(%s) mask has value %d; expected only 0 and 255", tc.imagePath, value)
+						break
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestGetCornerBackgroundMask_DiverseCases(t *testing.T) {
+	tests := []struct {
+		name              string
+		imagePath         string
+		flags             gocv.IMReadFlag
+		threshold         int
+		expectedMaskZeros int // number of background pixels in the mask
+	}{
+		{
+			name:              "rounded_button_masks_corners",
+			imagePath:         "testdata/masks/rounded_button.png",
+			flags:             gocv.IMReadColor,
+			threshold:         5,
+			expectedMaskZeros: 56,
+		},
+		{
+			name:              "rounded_button_grayscale_masks_corners",
+			imagePath:         "testdata/masks/rounded_button.png",
+			flags:             gocv.IMReadGrayScale,
+			threshold:         5,
+			expectedMaskZeros: 56,
+		},
+		{
+			name:              "solid_button_keeps_every_pixel",
+			imagePath:         "testdata/masks/opaque_alpha.png",
+			flags:             gocv.IMReadColor,
+			threshold:         5,
+			expectedMaskZeros: 0,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			imageMat := gocv.IMRead(tc.imagePath, tc.flags)
+			if imageMat.Empty() {
+				t.Fatalf("failed to load image: %s", tc.imagePath)
+			}
+			defer imageMat.Close()
+
+			maskMat, err := util.GetCornerBackgroundMask(imageMat, tc.threshold)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			defer maskMat.Close()
+
+			if maskMat.Rows() != imageMat.Rows() || maskMat.Cols() != imageMat.Cols() || maskMat.Channels() != 1 {
+				t.Fatalf("GetCornerBackgroundMask(%s) mask = %dx%dx%d; expected %dx%dx1",
+					tc.imagePath, maskMat.Cols(), maskMat.Rows(), maskMat.Channels(), imageMat.Cols(), imageMat.Rows())
+			}
+			zeros := maskMat.Rows()*maskMat.Cols() - gocv.CountNonZero(maskMat)
+			if zeros != tc.expectedMaskZeros {
+				t.Errorf("GetCornerBackgroundMask(%s) background pixels = %d; expected %d", tc.imagePath, zeros, tc.expectedMaskZeros)
+			}
+		})
+	}
+}
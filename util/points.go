@@ -1,6 +1,9 @@
 package util
 
-import "image"
+import (
+	"image"
+	"math"
+)
 
 // GetMinPointsFromRects returns the minimum (top-left) points of each rectangle in the input slice.
 func GetMinPointsFromRects(rects []image.Rectangle) []image.Point {
@@ -46,6 +49,49 @@ func GetReferencePoints(x int, ys []int) []image.Point {
 	return referencePoints
 }
 
+// EstimateReferencePointsPlacement estimates where reference points are from the min points of the
+// detected buttons inside the rows of bounds, the configured search rectangle: its Y range keeps out the
+// header and the navigation bar, while its X range is ignored, since the X coordinate is what is being
+// estimated. The X coordinate is the median X of those points, which is robust to a few false matches. The
+// search rectangle bounds the points whose X is within tolerance of that median, i.e. the visible part of
+// the list, expanded by tolerance on every side and clipped to the Y range of bounds.
+// Returns false if there are no points to estimate from.
+func EstimateReferencePointsPlacement(points []image.Point, bounds image.Rectangle, tolerance int) (int, image.Rectangle, bool) {
+	var rowPoints []image.Point
+	for _, point := range points {
+		if point.Y >= bounds.Min.Y && point.Y <= bounds.Max.Y {
+			rowPoints = append(rowPoints, point)
+		}
+	}
+	if len(rowPoints) == 0 {
+		return 0, image.Rectangle{}, false
+	}
+
+	var xCoordinates []float64
+	for _, point := range rowPoints {
+		xCoordinates = append(xCoordinates, float64(point.X))
+	}
+	x := int(math.Round(Median(xCoordinates)))
+
+	var searchRect image.Rectangle
+	for _, point := range rowPoints {
+		if point.X < x-tolerance || point.X > x+tolerance {
+			continue
+		}
+
+		pointRect := image.Rect(point.X, point.Y, point.X, point.Y).Inset(-tolerance)
+		if searchRect.Empty() {
+			searchRect = pointRect
+		} else {
+			searchRect = searchRect.Union(pointRect)
+		}
+	}
+	searchRect.Min.Y = max(searchRect.Min.Y, bounds.Min.Y)
+	searchRect.Max.Y = min(searchRect.Max.Y, bounds.Max.Y)
+
+	return x, searchRect, true
+}
+
 // pointInRect checks if a given point is inside the specified rectangle.
 func pointInRect(point image.Point, rect image.Rectangle) bool {
 	return point.X >= rect.Min.X &&
@@ -176,6 +176,109 @@ func TestGetReferencePoints_DiverseCases(t *testing.T) {
 	}
 }
 
+func TestEstimateReferencePointsPlacement_DiverseCases(t *testing.T) {
+	tests := []struct {
+		name           string
+		points         []image.Point
+		bounds         image.Rectangle
+		tolerance      int
+		expectedX      int
+		expectedBounds image.Rectangle
+		expectedFound  bool
+	}{
+		{
+			name:          "empty_points_not_found",
+			points:        nil,
+			bounds:        image.Rect(600, 308, 675, 1690),
+			tolerance:     10,
+			expectedFound: false,
+		},
+		{
+			name:           "single_point",
+			points:         []image.Point{{629, 392}},
+			bounds:         image.Rect(600, 308, 675, 1690),
+			tolerance:      10,
+			expectedX:      629,
+			expectedBounds: image.Rect(619, 382, 639, 402),
+			expectedFound:  true,
+		},
+		{
+			name:           "aligned_points",
+			points:         []image.Point{{629, 392}, {630, 541}, {628, 690}},
+			bounds:         image.Rect(600, 308, 675, 1690),
+			tolerance:      10,
+			expectedX:      629,
+			expectedBounds: image.Rect(618, 382, 640, 700),
+			expectedFound:  true,
+		},
+		{
+			name:           "outlier_ignored",
+			points:         []image.Point{{629, 392}, {630, 541}, {629, 690}, {120, 1800}},
+			bounds:         image.Rect(600, 308, 675, 1690),
+			tolerance:      10,
+			expectedX:      629,
+			expectedBounds: image.Rect(619, 382, 640, 700),
+			expectedFound:  true,
+		},
+		{
+			name:           "shifted_layout",
+			points:         []image.Point{{650, 400}, {650, 550}},
+			bounds:         image.Rect(600, 308, 675, 1690),
+			tolerance:      5,
+			expectedX:      650,
+			expectedBounds: image.Rect(645, 395, 655, 555),
+			expectedFound:  true,
+		},
+		{
+			// A button-like match in the header, aligned with the list buttons, must not widen the search rect
+			name:           "stray_match_above_configured_rect_rejected",
+			points:         []image.Point{{629, 120}, {629, 392}, {630, 541}, {629, 690}},
+			bounds:         image.Rect(600, 308, 675, 1690),
+			tolerance:      10,
+			expectedX:      629,
+			expectedBounds: image.Rect(619, 382, 640, 700),
+			expectedFound:  true,
+		},
+		{
+			name:           "stray_match_below_configured_rect_rejected",
+			points:         []image.Point{{629, 392}, {629, 541}, {629, 1750}},
+			bounds:         image.Rect(600, 308, 675, 1690),
+			tolerance:      10,
+			expectedX:      629,
+			expectedBounds: image.Rect(619, 382, 639, 551),
+			expectedFound:  true,
+		},
+		{
+			name:           "search_rect_clipped_to_configured_rows",
+			points:         []image.Point{{629, 312}, {629, 1686}},
+			bounds:         image.Rect(600, 308, 675, 1690),
+			tolerance:      10,
+			expectedX:      629,
+			expectedBounds: image.Rect(619, 308, 639, 1690),
+			expectedFound:  true,
+		},
+		{
+			name:          "only_stray_matches_not_found",
+			points:        []image.Point{{629, 120}, {629, 1800}},
+			bounds:        image.Rect(600, 308, 675, 1690),
+			tolerance:     10,
+			expectedFound: false,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			x, bounds, found := util.EstimateReferencePointsPlacement(tc.points, tc.bounds, tc.tolerance)
+			if found != tc.expectedFound {
+				t.Fatalf("EstimateReferencePointsPlacement(%v, %v, %d) found = %v; expected %v", tc.points, tc.bounds, tc.tolerance, found, tc.expectedFound)
+			}
+			if found && (x != tc.expectedX || bounds != tc.expectedBounds) {
+				t.Fatalf("EstimateReferencePointsPlacement(%v, %v, %d) = %d, %v; expected %d, %v",
+					tc.points, tc.bounds, tc.tolerance, x, bounds, tc.expectedX, tc.expectedBounds)
+			}
+		})
+	}
+}
+
 // --- helpers ---
 
 func pointsSliceEqual(a, b []image.Point) bool {
@@ -0,0 +1,181 @@
+package util
+
+import (
+	"image"
+
+	"github.com/palantir/stacktrace"
+	"gocv.io/x/gocv"
+)
+
+// Run is a range [Start, End) of consecutive indexes of a projection profile.
+type Run struct {
+	Start int
+	End   int
+}
+
+// GetGrayRegion returns the rect region of the image converted to grayscale.
+// The returned mat must be closed by the caller.
+func GetGrayRegion(imageMat gocv.Mat, rect image.Rectangle) (gocv.Mat, error) {
+	region := imageMat.Region(rect)
+	defer region.Close()
+
+	grayMat := gocv.NewMat()
+	if imageMat.Channels() == 1 {
+		err := region.CopyTo(&grayMat)
+		if err != nil {
+			grayMat.Close()
+			return gocv.Mat{}, stacktrace.Propagate(err, "failed to copy region %v", rect)
+		}
+		return grayMat, nil
+	}
+
+	code := gocv.ColorBGRToGray
+	if imageMat.Channels() == 4 {
+		code = gocv.ColorBGRAToGray
+	}
+	err := gocv.CvtColor(region, &grayMat, code)
+	if err != nil {
+		grayMat.Close()
+		return gocv.Mat{}, stacktrace.Propagate(err, "failed to convert region %v to grayscale", rect)
+	}
+
+	return grayMat, nil
+}
+
+// GetSaturationRegion returns the saturation channel of the rect region of a BGR image.
+// The returned mat must be closed by the caller.
+func GetSaturationRegion(imageMat gocv.Mat, rect image.Rectangle) (gocv.Mat, error) {
+	region := imageMat.Region(rect)
+	defer region.Close()
+
+	hsvMat := gocv.NewMat()
+	defer hsvMat.Close()
+	err := gocv.CvtColor(region, &hsvMat, gocv.ColorBGRToHSV)
+	if err != nil {
+		return gocv.Mat{}, stacktrace.Propagate(err, "failed to convert region %v to HSV", rect)
+	}
+
+	channels := gocv.Split(hsvMat)
+	channels[0].Close()
+	channels[2].Close()
+
+	return channels[1], nil
+}
+
+// GetBackgroundLevel returns the median gray value of the rect region of the image, which is the
+// background level of regions mostly covered by background, such as text lines.
+func GetBackgroundLevel(imageMat gocv.Mat, rect image.Rectangle) (uint8, error) {
+	histogram, total, err := getGrayHistogram(imageMat, rect)
+	if err != nil {
+		return 0, stacktrace.Propagate(err, "failed to get gray histogram")
+	}
+
+	half := (total + 1) / 2
+	count := 0
+	for level, levelCount := range histogram {
+		count += levelCount
+		if count >= half {
+			return uint8(level), nil
+		}
+	}
+
+	return 0, stacktrace.NewError("region %v is empty", rect)
+}
+
+// GetDominantLevel returns the most frequent gray value of the rect region of the image, which is the
+// background level of regions whose background is flat but may cover less than half of them, such as
+// a list partly hidden by the keyboard.
+func GetDominantLevel(imageMat gocv.Mat, rect image.Rectangle) (uint8, error) {
+	histogram, total, err := getGrayHistogram(imageMat, rect)
+	if err != nil {
+		return 0, stacktrace.Propagate(err, "failed to get gray histogram")
+	}
+	if total == 0 {
+		return 0, stacktrace.NewError("region %v is empty", rect)
+	}
+
+	dominant := 0
+	for level, levelCount := range histogram {
+		if levelCount > histogram[dominant] {
+			dominant = level
+		}
+	}
+
+	return uint8(dominant), nil
+}
+
+// getGrayHistogram returns the number of pixels of each gray value in the rect region of the image,
+// along with the number of pixels of the region.
+func getGrayHistogram(imageMat gocv.Mat, rect image.Rectangle) ([256]int, int, error) {
+	var histogram [256]int
+	grayMat, err := GetGrayRegion(imageMat, rect)
+	if err != nil {
+		return histogram, 0, stacktrace.Propagate(err, "failed to get gray region")
+	}
+	defer grayMat.Close()
+
+	for y := 0; y < grayMat.Rows(); y++ {
+		for x := 0; x < grayMat.Cols(); x++ {
+			histogram[grayMat.GetUCharAt(y, x)]++
+		}
+	}
+
+	return histogram, grayMat.Rows() * grayMat.Cols(), nil
+}
+
+// GetProjectionProfiles counts, for each row and for each column of the rect region of the image,
+// the pixels whose gray value differs from background by more than threshold (ink pixels).
+// Returns the horizontal profile (one count per row) and the vertical profile (one count per column).
+func GetProjectionProfiles(imageMat gocv.Mat, rect image.Rectangle, background uint8, threshold int) ([]int, []int, error) {
+	grayMat, err := GetGrayRegion(imageMat, rect)
+	if err != nil {
+		return nil, nil, stacktrace.Propagate(err, "failed to get gray region")
+	}
+	defer grayMat.Close()
+
+	rows := make([]int, grayMat.Rows())
+	cols := make([]int, grayMat.Cols())
+	for y := 0; y < grayMat.Rows(); y++ {
+		for x := 0; x < grayMat.Cols(); x++ {
+			diff := int(grayMat.GetUCharAt(y, x)) - int(background)
+			if diff > threshold || -diff > threshold {
+				rows[y]++
+				cols[x]++
+			}
+		}
+	}
+
+	return rows, cols, nil
+}
+
+// FindRuns returns the runs of a projection profile whose values are at least minValue.
+// Runs separated by gaps of at most maxGap are merged (e.g. accents above letters), and
+// merged runs shorter than minLength are dropped (e.g. noise).
+//
+// Example:
+//
+//	profile := []int{0, 5, 6, 0, 4, 0, 0, 0, 1, 0}
+//	runs := FindRuns(profile, 1, 1, 2)
+//	// runs = [{1 5}]
+func FindRuns(profile []int, minValue, maxGap, minLength int) []Run {
+	var runs []Run
+	for i, value := range profile {
+		if value < minValue {
+			continue
+		}
+		if len(runs) > 0 && i-runs[len(runs)-1].End <= maxGap {
+			runs[len(runs)-1].End = i + 1
+			continue
+		}
+		runs = append(runs, Run{Start: i, End: i + 1})
+	}
+
+	var result []Run
+	for _, run := range runs {
+		if run.End-run.Start >= minLength {
+			result = append(result, run)
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,118 @@
+package util_test
+
+import (
+	"image"
+	"testing"
+
+	"gocv.io/x/gocv"
+
+	"github.com/rogeriofbrito/go-insta-scraper-v2/util"
+)
+
+func TestFindRuns_DiverseCases(t *testing.T) {
+	tests := []struct {
+		name      string
+		profile   []int
+		minValue  int
+		maxGap    int
+		minLength int
+		expected  []util.Run
+	}{
+		{
+			name:      "empty_profile",
+			profile:   nil,
+			minValue:  1,
+			maxGap:    0,
+			minLength: 1,
+			expected:  nil,
+		},
+		{
+			name:      "separate_runs",
+			profile:   []int{0, 3, 4, 0, 0, 2, 2, 2, 0},
+			minValue:  1,
+			maxGap:    0,
+			minLength: 1,
+			expected:  []util.Run{{Start: 1, End: 3}, {Start: 5, End: 8}},
+		},
+		{
+			name:      "small_gap_is_merged",
+			profile:   []int{0, 5, 6, 0, 4, 0, 0, 0, 1, 0},
+			minValue:  1,
+			maxGap:    1,
+			minLength: 2,
+			expected:  []util.Run{{Start: 1, End: 5}},
+		},
+		{
+			name:      "values_below_min_value_are_gaps",
+			profile:   []int{1, 9, 9, 1, 9},
+			minValue:  2,
+			maxGap:    0,
+			minLength: 1,
+			expected:  []util.Run{{Start: 1, End: 3}, {Start: 4, End: 5}},
+		},
+		{
+			name:      "short_runs_are_dropped",
+			profile:   []int{1, 0, 0, 1, 1, 1, 0, 1},
+			minValue:  1,
+			maxGap:    0,
+			minLength: 3,
+			expected:  []util.Run{{Start: 3, End: 6}},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			result := util.FindRuns(tc.profile, tc.minValue, tc.maxGap, tc.minLength)
+			if len(result) != len(tc.expected) {
+				t.Fatalf("FindRuns(%v) = %v; expected %v", tc.profile, result, tc.expected)
+			}
+			for i := range result {
+				if result[i] != tc.expected[i] {
+					t.Fatalf("FindRuns(%v) = %v; expected %v", tc.profile, result, tc.expected)
+				}
+			}
+		})
+	}
+}
+
+func TestGetProjectionProfiles_Gradient(t *testing.T) {
+	// 40x20 image where the gray value of column x is 6*x
+	imagePath := "testdata/masks/no_alpha.png"
+	imageMat := gocv.IMRead(imagePath, gocv.IMReadGrayScale)
+	if imageMat.Empty() {
+		t.Fatalf("failed to load image: %s", imagePath)
+	}
+	defer imageMat.Close()
+
+	rect := image.Rect(0, 0, imageMat.Cols(), imageMat.Rows())
+	background, err := util.GetBackgroundLevel(imageMat, rect)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if background != 114 {
+		t.Errorf("GetBackgroundLevel() = %d; expected 114", background)
+	}
+
+	// Columns 14 to 24 are within 30 of the background
+	rows, cols, err := util.GetProjectionProfiles(imageMat, rect, background, 30)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(rows) != 20 || len(cols) != 40 {
+		t.Fatalf("GetProjectionProfiles() sizes = %d, %d; expected 20, 40", len(rows), len(cols))
+	}
+	for y, count := range rows {
+		if count != 29 {
+			t.Errorf("GetProjectionProfiles() row %d = %d; expected 29", y, count)
+		}
+	}
+	for x, count := range cols {
+		expected := 20
+		if x >= 14 && x <= 24 {
+			expected = 0
+		}
+		if count != expected {
+			t.Errorf("GetProjectionProfiles() column %d = %d; expected %d", x, count, expected)
+		}
+	}
+}
@@ -13,7 +13,13 @@ import (
 // rect: the region of interest as an image.Rectangle
 // threshold: the maximum allowed difference between pixel values
 // Returns true if the region is uniform, false otherwise.
+// Parts of rect outside the image are ignored; a rect entirely outside it is not uniform.
 func IsUniformRegion(imageMat gocv.Mat, rect image.Rectangle, threshold int) bool {
+	rect, _ = ClipRect(rect, image.Rect(0, 0, imageMat.Cols(), imageMat.Rows()))
+	if rect.Empty() {
+		return false
+	}
+
 	// Extract the region of interest from the image.
 	region := imageMat.Region(rect)
 	defer region.Close() // Ensure the region is released after use.
@@ -61,3 +67,14 @@ func IsUniformRegion(imageMat gocv.Mat, rect image.Rectangle, threshold int) boo
 		return true // All pixels are within the threshold for all channels.
 	}
 }
+
+// ClipRect returns the part of rect inside bounds and whether anything of rect was left out.
+// An empty rect is never cut.
+func ClipRect(rect, bounds image.Rectangle) (image.Rectangle, bool) {
+	if rect.Empty() {
+		return rect, false
+	}
+
+	clipped := rect.Intersect(bounds)
+	return clipped, clipped != rect
+}
@@ -91,6 +91,81 @@ func TestIsUniformRegion_DiverseCases(t *testing.T) {
 			if got != tc.expected {
 				t.Errorf("IsUniformRegion(%s, %v, %d) = %v; want %v", tc.imagePath, rect, tc.threshold, got, tc.expected)
 			}
+
+			// Parts of the rect outside the image are ignored
+			overflowingRect := image.Rect(-10, -10, imageMat.Cols()+10, imageMat.Rows()+10)
+			got = util.IsUniformRegion(imageMat, overflowingRect, tc.threshold)
+			if got != tc.expected {
+				t.Errorf("IsUniformRegion(%s, %v, %d) = %v; want %v", tc.imagePath, overflowingRect, tc.threshold, got, tc.expected)
+			}
+		})
+	}
+}
+
+func TestIsUniformRegion_RectOutsideImage(t *testing.T) {
+	imagePath := "testdata/points/uniform_images/image_1.png"
+	imageMat := gocv.IMRead(imagePath, gocv.IMReadColor)
+	if imageMat.Empty() {
+		t.Fatalf("failed to load image: %s", imagePath)
+	}
+	defer imageMat.Close()
+
+	rect := image.Rect(imageMat.Cols(), 0, imageMat.Cols()+10, 10)
+	if util.IsUniformRegion(imageMat, rect, 5) {
+		t.Errorf("IsUniformRegion(%s, %v, 5) = true; want false", imagePath, rect)
+	}
+}
+
+func TestClipRect_DiverseCases(t *testing.T) {
+	bounds := image.Rect(0, 0, 100, 200)
+
+	tests := []struct {
+		name            string
+		rect            image.Rectangle
+		expectedRect    image.Rectangle
+		expectedClipped bool
+	}{
+		{
+			name:         "rect_inside_is_kept",
+			rect:         image.Rect(10, 20, 30, 40),
+			expectedRect: image.Rect(10, 20, 30, 40),
+		},
+		{
+			name:         "rect_equal_to_bounds_is_kept",
+			rect:         bounds,
+			expectedRect: bounds,
+		},
+		{
+			name:            "rect_above_top_edge_is_clipped",
+			rect:            image.Rect(10, -15, 30, 20),
+			expectedRect:    image.Rect(10, 0, 30, 20),
+			expectedClipped: true,
+		},
+		{
+			name:            "rect_below_bottom_edge_is_clipped",
+			rect:            image.Rect(10, 180, 30, 230),
+			expectedRect:    image.Rect(10, 180, 30, 200),
+			expectedClipped: true,
+		},
+		{
+			name:            "rect_outside_becomes_empty",
+			rect:            image.Rect(10, 210, 30, 230),
+			expectedRect:    image.Rectangle{},
+			expectedClipped: true,
+		},
+		{
+			name:         "empty_rect_is_not_clipped",
+			rect:         image.Rectangle{},
+			expectedRect: image.Rectangle{},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			rect, clipped := util.ClipRect(tc.rect, bounds)
+			if rect != tc.expectedRect || clipped != tc.expectedClipped {
+				t.Errorf("ClipRect(%v, %v) = %v, %v; expected %v, %v", tc.rect, bounds, rect, clipped, tc.expectedRect, tc.expectedClipped)
+			}
 		})
 	}
 }
@@ -0,0 +1,81 @@
+package util
+
+import (
+	"math"
+	"sort"
+)
+
+// InferMissingCoordinates fits a spacing model to the coordinates of evenly spaced elements, such as
+// the Y coordinates of the rows of a list, and fills the gaps left by elements that were not detected.
+// Gaps shorter than minSpacing (e.g. two matches inside the same row) are not spacings and are ignored.
+// The spacing is the average of the remaining gaps within tolerance of their median, and at least two
+// gaps must agree on it; otherwise the input is returned as is. A gap that is k times the spacing, give
+// or take tolerance, gets k-1 evenly spaced coordinates inserted.
+// Returns the sorted coordinates and, for each of them, whether it was inferred.
+//
+// Example:
+//
+//	coordinates := []int{100, 200, 400, 500}
+//	minSpacing := 50
+//	tolerance := 10
+//	result, inferred := InferMissingCoordinates(coordinates, minSpacing, tolerance)
+//	// result = [100, 200, 300, 400, 500]
+//	// inferred = [false, false, true, false, false]
+func InferMissingCoordinates(coordinates []int, minSpacing, tolerance int) ([]int, []bool) {
+	sorted := append([]int(nil), coordinates...)
+	sort.Ints(sorted)
+	inferred := make([]bool, len(sorted))
+	spacing, found := getSpacing(sorted, minSpacing, tolerance)
+	if !found {
+		return sorted, inferred
+	}
+
+	result := []int{sorted[0]}
+	resultInferred := []bool{false}
+	for i := 1; i < len(sorted); i++ {
+		gap := float64(sorted[i] - sorted[i-1])
+		steps := math.Round(gap / spacing)
+		if steps >= 2 && math.Abs(gap-steps*spacing) <= float64(tolerance) {
+			// Spread the inserted coordinates evenly over the gap
+			for step := 1; step < int(steps); step++ {
+				result = append(result, sorted[i-1]+int(math.Round(gap*float64(step)/steps)))
+				resultInferred = append(resultInferred, true)
+			}
+		}
+
+		result = append(result, sorted[i])
+		resultInferred = append(resultInferred, false)
+	}
+
+	return result, resultInferred
+}
+
+// getSpacing returns the average of the gaps between neighbors, at least minSpacing long, that are within
+// tolerance of the median of those gaps. The median is not moved by a few spurious short or missing-row long
+// gaps. Returns false if fewer than two gaps agree on the spacing.
+func getSpacing(sorted []int, minSpacing, tolerance int) (float64, bool) {
+	var gaps []float64
+	for i := 1; i < len(sorted); i++ {
+		gap := sorted[i] - sorted[i-1]
+		if gap >= minSpacing && gap > 0 {
+			gaps = append(gaps, float64(gap))
+		}
+	}
+	if len(gaps) < 2 {
+		return 0, false
+	}
+
+	median := Median(gaps)
+	sum, count := 0.0, 0
+	for _, gap := range gaps {
+		if math.Abs(gap-median) <= float64(tolerance) {
+			sum += gap
+			count++
+		}
+	}
+	if count < 2 {
+		return 0, false
+	}
+
+	return sum / float64(count), true
+}
@@ -0,0 +1,123 @@
+package util_test
+
+import (
+	"testing"
+
+	"github.com/rogeriofbrito/go-insta-scraper-v2/util"
+)
+
+func TestInferMissingCoordinates_DiverseCases(t *testing.T) {
+	tests := []struct {
+		name             string
+		coordinates      []int
+		minSpacing       int
+		tolerance        int
+		expected         []int
+		expectedInferred []bool
+	}{
+		{
+			name:             "empty_input_returns_empty",
+			coordinates:      nil,
+			minSpacing:       50,
+			tolerance:        10,
+			expected:         []int{},
+			expectedInferred: []bool{},
+		},
+		{
+			name:             "too_few_coordinates_returned_as_is",
+			coordinates:      []int{100, 300},
+			minSpacing:       50,
+			tolerance:        10,
+			expected:         []int{100, 300},
+			expectedInferred: []bool{false, false},
+		},
+		{
+			name:             "no_gaps",
+			coordinates:      []int{100, 200, 300, 400},
+			minSpacing:       50,
+			tolerance:        10,
+			expected:         []int{100, 200, 300, 400},
+			expectedInferred: []bool{false, false, false, false},
+		},
+		{
+			name:             "single_missing_row",
+			coordinates:      []int{100, 200, 400, 500},
+			minSpacing:       50,
+			tolerance:        10,
+			expected:         []int{100, 200, 300, 400, 500},
+			expectedInferred: []bool{false, false, true, false, false},
+		},
+		{
+			name:             "two_missing_rows_unsorted_input",
+			coordinates:      []int{600, 100, 300, 200},
+			minSpacing:       50,
+			tolerance:        10,
+			expected:         []int{100, 200, 300, 400, 500, 600},
+			expectedInferred: []bool{false, false, false, true, true, false},
+		},
+		{
+			name:             "single_gap_agreeing_on_spacing_left_alone",
+			coordinates:      []int{500, 100, 200},
+			minSpacing:       50,
+			tolerance:        10,
+			expected:         []int{100, 200, 500},
+			expectedInferred: []bool{false, false, false},
+		},
+		{
+			name:             "close_pair_of_matches_does_not_shrink_spacing",
+			coordinates:      []int{100, 120, 270, 420, 570},
+			minSpacing:       132,
+			tolerance:        10,
+			expected:         []int{100, 120, 270, 420, 570},
+			expectedInferred: []bool{false, false, false, false, false},
+		},
+		{
+			name:             "close_pair_of_matches_and_missing_row",
+			coordinates:      []int{100, 120, 270, 570, 720},
+			minSpacing:       132,
+			tolerance:        10,
+			expected:         []int{100, 120, 270, 420, 570, 720},
+			expectedInferred: []bool{false, false, false, true, false, false},
+		},
+		{
+			name:             "slightly_irregular_spacing",
+			coordinates:      []int{100, 198, 301, 502},
+			minSpacing:       50,
+			tolerance:        10,
+			expected:         []int{100, 198, 301, 402, 502},
+			expectedInferred: []bool{false, false, false, true, false},
+		},
+		{
+			name:             "gap_not_multiple_of_spacing_left_alone",
+			coordinates:      []int{100, 200, 300, 450},
+			minSpacing:       50,
+			tolerance:        10,
+			expected:         []int{100, 200, 300, 450},
+			expectedInferred: []bool{false, false, false, false},
+		},
+		{
+			name:             "duplicated_coordinates_left_alone",
+			coordinates:      []int{100, 100, 300},
+			minSpacing:       50,
+			tolerance:        10,
+			expected:         []int{100, 100, 300},
+			expectedInferred: []bool{false, false, false},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, gotInferred := util.InferMissingCoordinates(tc.coordinates, tc.minSpacing, tc.tolerance)
+			if len(got) != len(tc.expected) || len(gotInferred) != len(tc.expectedInferred) {
+				t.Fatalf("InferMissingCoordinates(%v, %d, %d) = %v, %v; expected %v, %v",
+					tc.coordinates, tc.minSpacing, tc.tolerance, got, gotInferred, tc.expected, tc.expectedInferred)
+			}
+			for i := range got {
+				if got[i] != tc.expected[i] || gotInferred[i] != tc.expectedInferred[i] {
+					t.Fatalf("InferMissingCoordinates(%v, %d, %d) = %v, %v; expected %v, %v",
+						tc.coordinates, tc.minSpacing, tc.tolerance, got, gotInferred, tc.expected, tc.expectedInferred)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,44 @@
+package util
+
+import (
+	"math"
+	"sort"
+)
+
+// Percentile returns the p-th percentile (0 <= p <= 100) of nums, interpolating linearly
+// between the two closest ranks. The input slice is not modified.
+// Returns NaN if nums is empty.
+//
+// Example:
+//
+//	nums := []float64{4, 1, 3, 2}
+//	result := Percentile(nums, 50)
+//	// result = 2.5
+func Percentile(nums []float64, p float64) float64 {
+	if len(nums) == 0 {
+		return math.NaN()
+	}
+
+	sorted := append([]float64(nil), nums...)
+	sort.Float64s(sorted)
+
+	// Position of the percentile between the first (0) and last (len-1) ranks
+	rank := p / 100 * float64(len(sorted)-1)
+	lower := int(math.Floor(rank))
+	upper := int(math.Ceil(rank))
+	if lower < 0 {
+		return sorted[0]
+	}
+	if upper >= len(sorted) {
+		return sorted[len(sorted)-1]
+	}
+
+	weight := rank - float64(lower)
+	return sorted[lower]*(1-weight) + sorted[upper]*weight
+}
+
+// Median returns the median of nums. The input slice is not modified.
+// Returns NaN if nums is empty.
+func Median(nums []float64) float64 {
+	return Percentile(nums, 50)
+}
@@ -0,0 +1,84 @@
+package util_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/rogeriofbrito/go-insta-scraper-v2/util"
+)
+
+func TestPercentile_DiverseCases(t *testing.T) {
+	tests := []struct {
+		name     string
+		nums     []float64
+		p        float64
+		expected float64
+	}{
+		{
+			name:     "single_value",
+			nums:     []float64{7},
+			p:        50,
+			expected: 7,
+		},
+		{
+			name:     "median_odd_length",
+			nums:     []float64{3, 1, 2},
+			p:        50,
+			expected: 2,
+		},
+		{
+			name:     "median_even_length_interpolates",
+			nums:     []float64{4, 1, 3, 2},
+			p:        50,
+			expected: 2.5,
+		},
+		{
+			name:     "zero_percentile_is_min",
+			nums:     []float64{5, -1, 3},
+			p:        0,
+			expected: -1,
+		},
+		{
+			name:     "hundredth_percentile_is_max",
+			nums:     []float64{5, -1, 3},
+			p:        100,
+			expected: 5,
+		},
+		{
+			name:     "quartile_interpolates",
+			nums:     []float64{10, 20, 30, 40, 50},
+			p:        25,
+			expected: 20,
+		},
+		{
+			name:     "out_of_range_percentile_is_clamped",
+			nums:     []float64{10, 20, 30},
+			p:        150,
+			expected: 30,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got := util.Percentile(tc.nums, tc.p)
+			if !floatsAlmostEqual(got, tc.expected) {
+				t.Fatalf("Percentile(%v, %v) = %v; expected %v", tc.nums, tc.p, got, tc.expected)
+			}
+		})
+	}
+}
+
+func TestPercentile_DoesNotModifyInput(t *testing.T) {
+	nums := []float64{3, 1, 2}
+	util.Percentile(nums, 50)
+	if !floatSlicesAlmostEqual(nums, []float64{3, 1, 2}) {
+		t.Fatalf("Percentile modified its input: %v", nums)
+	}
+}
+
+func TestMedian_EmptyInputReturnsNaN(t *testing.T) {
+	got := util.Median(nil)
+	if !math.IsNaN(got) {
+		t.Fatalf("Median(nil) = %v; expected NaN", got)
+	}
+}